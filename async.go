@@ -0,0 +1,238 @@
+package gologops
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy tells what an AsyncWriter does with a line when its queue is full
+type OverflowPolicy int
+
+const (
+	// Block waits until there is room in the queue
+	Block OverflowPolicy = iota
+	// DropNewest discards the line
+	DropNewest
+	// DropDebugFirst discards DebugLevel lines: an incoming one, or the oldest
+	// queued one to make room for a line of another level. Other lines wait
+	// for room only when no DebugLevel line is queued
+	DropDebugFirst
+)
+
+// ErrClosed is returned when writing to a closed AsyncWriter
+var ErrClosed = errors.New("gologops: writer closed")
+
+// bufferWriter is implemented by writers that take ownership of the pooled
+// buffers of a Logger, knowing the level of every line
+type bufferWriter interface {
+	writeBuffer(buffer *bytes.Buffer, lvl Level) error
+}
+
+type asyncLine struct {
+	buffer  *bytes.Buffer
+	level   Level
+	flushed chan struct{} // not a line but a flush request if not nil
+}
+
+// AsyncWriter writes lines from a background goroutine, so logging does not
+// wait for a slow writer. Lines go through a bounded queue; what happens when
+// it is full depends on the OverflowPolicy. Write errors are returned by Flush
+// and Close. Use it as the writer of a Logger:
+//
+//	aw := gologops.NewAsyncWriter(os.Stdout, 1024, gologops.DropDebugFirst)
+//	defer aw.Close()
+//	l.SetWriter(aw)
+type AsyncWriter struct {
+	w       io.Writer
+	policy  OverflowPolicy
+	done    chan struct{}
+	dropped uint64
+
+	mu       sync.Mutex
+	notEmpty sync.Cond
+	notFull  sync.Cond
+	queue    []asyncLine // ring of queued lines, from head
+	head, n  int
+	closed   bool
+
+	errMu sync.Mutex
+	err   error // first write error since the last flush
+}
+
+// NewAsyncWriter returns an AsyncWriter for w with room for size lines
+func NewAsyncWriter(w io.Writer, size int, policy OverflowPolicy) *AsyncWriter {
+	aw := &AsyncWriter{
+		w:      w,
+		policy: policy,
+		queue:  make([]asyncLine, max(size, 1)),
+		done:   make(chan struct{}),
+	}
+	aw.notEmpty.L = &aw.mu
+	aw.notFull.L = &aw.mu
+	go aw.run()
+	return aw
+}
+
+func (aw *AsyncWriter) run() {
+	for {
+		aw.mu.Lock()
+		for aw.n == 0 && !aw.closed {
+			aw.notEmpty.Wait()
+		}
+		if aw.n == 0 {
+			aw.mu.Unlock()
+			break
+		}
+		line := aw.queue[aw.head]
+		aw.queue[aw.head] = asyncLine{}
+		aw.head = (aw.head + 1) % len(aw.queue)
+		aw.n--
+		aw.notFull.Signal()
+		aw.mu.Unlock()
+
+		if line.flushed != nil {
+			if f, ok := aw.w.(flusher); ok {
+				aw.setErr(f.Flush())
+			} else if s, ok := aw.w.(syncer); ok {
+				s.Sync() // not every file can sync, stdout to a pipe for one
+			}
+			close(line.flushed)
+			continue
+		}
+		_, err := aw.w.Write(line.buffer.Bytes())
+		aw.setErr(err)
+		putBuffer(line.buffer)
+	}
+	close(aw.done)
+}
+
+func (aw *AsyncWriter) setErr(err error) {
+	if err == nil {
+		return
+	}
+	aw.errMu.Lock()
+	if aw.err == nil {
+		aw.err = err
+	}
+	aw.errMu.Unlock()
+}
+
+func (aw *AsyncWriter) takeErr() error {
+	aw.errMu.Lock()
+	defer aw.errMu.Unlock()
+	err := aw.err
+	aw.err = nil
+	return err
+}
+
+// Write queues a copy of p, as a line of InfoLevel
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	b := getBuffer()
+	b.Write(p)
+	if err := aw.writeBuffer(b, InfoLevel); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (aw *AsyncWriter) writeBuffer(buffer *bytes.Buffer, lvl Level) error {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	for !aw.closed && aw.n == len(aw.queue) {
+		if aw.policy == DropNewest || (aw.policy == DropDebugFirst && lvl <= DebugLevel) {
+			atomic.AddUint64(&aw.dropped, 1)
+			putBuffer(buffer)
+			return nil
+		}
+		if aw.policy == DropDebugFirst && aw.evictDebug() {
+			break
+		}
+		aw.notFull.Wait()
+	}
+	if aw.closed {
+		putBuffer(buffer)
+		return ErrClosed
+	}
+	aw.push(asyncLine{buffer: buffer, level: lvl})
+	return nil
+}
+
+// push queues line, once there is room for it
+func (aw *AsyncWriter) push(line asyncLine) {
+	aw.queue[(aw.head+aw.n)%len(aw.queue)] = line
+	aw.n++
+	aw.notEmpty.Signal()
+}
+
+// evictDebug discards the oldest queued DebugLevel line, moving the lines
+// after it forward, and tells whether there was any
+func (aw *AsyncWriter) evictDebug() bool {
+	for i := 0; i < aw.n; i++ {
+		at := (aw.head + i) % len(aw.queue)
+		if line := aw.queue[at]; line.flushed != nil || line.level > DebugLevel {
+			continue
+		}
+		putBuffer(aw.queue[at].buffer)
+		for ; i < aw.n-1; i++ {
+			next := (aw.head + i + 1) % len(aw.queue)
+			aw.queue[at] = aw.queue[next]
+			at = next
+		}
+		aw.queue[at] = asyncLine{}
+		aw.n--
+		atomic.AddUint64(&aw.dropped, 1)
+		return true
+	}
+	return false
+}
+
+// Dropped returns the number of lines discarded because the queue was full
+func (aw *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&aw.dropped)
+}
+
+// Flush waits until every line queued so far is written, flushes or syncs
+// the underlying writer if it knows how, and returns the first write error
+// since the previous Flush
+func (aw *AsyncWriter) Flush() error {
+	aw.mu.Lock()
+	for !aw.closed && aw.n == len(aw.queue) {
+		aw.notFull.Wait()
+	}
+	if aw.closed {
+		aw.mu.Unlock()
+		return ErrClosed
+	}
+	flushed := make(chan struct{})
+	aw.push(asyncLine{flushed: flushed})
+	aw.mu.Unlock()
+	<-flushed
+	return aw.takeErr()
+}
+
+// Close flushes the queue and stops the background goroutine. Lines written
+// after Close are discarded with ErrClosed. Close does not close the
+// underlying writer
+func (aw *AsyncWriter) Close() error {
+	err := aw.Flush()
+	if err == ErrClosed {
+		return err
+	}
+	aw.mu.Lock()
+	if aw.closed {
+		aw.mu.Unlock()
+		return ErrClosed
+	}
+	aw.closed = true
+	aw.notEmpty.Broadcast()
+	aw.notFull.Broadcast()
+	aw.mu.Unlock()
+	<-aw.done
+	if lastErr := aw.takeErr(); err == nil {
+		err = lastErr
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package gologops
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testingGateWriter blocks every Write until the gate is opened, telling
+// through entered that a Write is waiting
+type testingGateWriter struct {
+	mu      sync.Mutex
+	buffer  bytes.Buffer
+	gate    chan struct{}
+	entered chan struct{}
+	once    sync.Once
+}
+
+func newTestingGateWriter() *testingGateWriter {
+	return &testingGateWriter{gate: make(chan struct{}), entered: make(chan struct{})}
+}
+
+func (w *testingGateWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.entered) })
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.Write(p)
+}
+
+func (w *testingGateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.String()
+}
+
+func TestAsyncWriterOrder(t *testing.T) {
+	var buffer bytes.Buffer
+	aw := NewAsyncWriter(&buffer, 10, Block)
+	l := NewLoggerWithFormatter(aw, TextFormatter{TimeFormat: staticTime})
+	var want strings.Builder
+	for i := 0; i < 100; i++ {
+		if err := l.LogC(logLine{level: InfoLevel, message: "line %d", params: []interface{}{i}}); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&want, "static INFO\t line %d\n", i)
+	}
+	if err := aw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != want.String() {
+		t.Errorf("lines: want %q, got %q", want.String(), buffer.String())
+	}
+	if aw.Dropped() != 0 {
+		t.Errorf("blocking writer dropped %d lines", aw.Dropped())
+	}
+	if err := aw.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+// fillAsyncWriter leaves one line being written and size lines queued
+func fillAsyncWriter(t *testing.T, l *Logger, gw *testingGateWriter, size int) {
+	l.Info("first")
+	<-gw.entered
+	for i := 0; i < size; i++ {
+		l.Info("queued")
+	}
+}
+
+func TestAsyncWriterDropNewest(t *testing.T) {
+	gw := newTestingGateWriter()
+	aw := NewAsyncWriter(gw, 3, DropNewest)
+	l := NewLoggerWithFormatter(aw, JSONFormatter{})
+	fillAsyncWriter(t, l, gw, 3)
+	l.Error("dropped 1")
+	l.Error("dropped 2")
+	if aw.Dropped() != 2 {
+		t.Errorf("dropped: want 2, got %d", aw.Dropped())
+	}
+	close(gw.gate)
+	aw.Close()
+	if out := gw.String(); strings.Count(out, "\n") != 4 || strings.Contains(out, "dropped") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestAsyncWriterDropDebugFirst(t *testing.T) {
+	gw := newTestingGateWriter()
+	aw := NewAsyncWriter(gw, 2, DropDebugFirst)
+	l := NewLoggerWithFormatter(aw, JSONFormatter{})
+	fillAsyncWriter(t, l, gw, 2)
+	l.Debug("dropped")
+	if aw.Dropped() != 1 {
+		t.Errorf("dropped: want 1, got %d", aw.Dropped())
+	}
+	written := make(chan struct{})
+	go func() {
+		l.Warn("waits for room")
+		close(written)
+	}()
+	close(gw.gate)
+	<-written
+	aw.Close()
+	if out := gw.String(); !strings.Contains(out, "waits for room") || strings.Contains(out, `"dropped"`) {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestAsyncWriterEvictDebug(t *testing.T) {
+	gw := newTestingGateWriter()
+	aw := NewAsyncWriter(gw, 3, DropDebugFirst)
+	l := NewLoggerWithFormatter(aw, TextFormatter{TimeFormat: staticTime})
+	l.Info("first")
+	<-gw.entered
+	l.Debug("debug 1")
+	l.Info("info")
+	l.Debug("debug 2")
+	l.Error("error 1") // the writer is still blocked: both take the place of a debug line
+	l.Error("error 2")
+	if aw.Dropped() != 2 {
+		t.Errorf("dropped: want 2, got %d", aw.Dropped())
+	}
+	close(gw.gate)
+	aw.Close()
+	want := "static INFO\t first\nstatic INFO\t info\nstatic ERROR\t error 1\nstatic ERROR\t error 2\n"
+	if out := gw.String(); out != want {
+		t.Errorf("lines: want %q, got %q", want, out)
+	}
+}
+
+func TestAsyncWriterErrors(t *testing.T) {
+	aw := NewAsyncWriter(testingBadWriter{}, 10, Block)
+	l := NewLoggerWithWriter(aw)
+	if err := l.LogC(logLine{level: InfoLevel, message: "msg"}); err != nil {
+		t.Errorf("write error should be returned by Flush, got %v from LogC", err)
+	}
+	if err := aw.Flush(); err != errTestingBadWriter {
+		t.Errorf("Flush: want %v, got %v", errTestingBadWriter, err)
+	}
+	if err := aw.Flush(); err != nil {
+		t.Errorf("error returned twice: %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if err := l.LogC(logLine{level: InfoLevel, message: "msg"}); err != ErrClosed {
+		t.Errorf("write after Close: want %v, got %v", ErrClosed, err)
+	}
+	if err := aw.Close(); err != ErrClosed {
+		t.Errorf("second Close: want %v, got %v", ErrClosed, err)
+	}
+}
+
+func TestAsyncWriterFatalFlush(t *testing.T) {
+	var w testingSyncWriter
+	aw := NewAsyncWriter(&w, 10, Block)
+	l := NewLoggerWithWriter(aw)
+	l.SetExitFunc(func(int) {
+		if w.Len() == 0 || !w.synced {
+			t.Error("exit before flushing the async writer")
+		}
+	})
+	l.Fatal("bye")
+	aw.Close()
+}
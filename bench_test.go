@@ -0,0 +1,163 @@
+package gologops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var benchContext = C{"user": "bob", "path": "/v1/users", "status": 200, "latency": 3 * time.Millisecond}
+
+func benchLogger(f Formatter, flags int32) *Logger {
+	l := NewLoggerWithFormatter(io.Discard, f)
+	l.SetContext(C{"svc": "bench", "version": "1.2.3"})
+	l.SetFlags(flags)
+	return l
+}
+
+func BenchmarkInfoC(b *testing.B) {
+	for _, bench := range []struct {
+		name  string
+		f     Formatter
+		flags int32
+	}{
+		{"JSON", JSONFormatter{}, 0},
+		{"Text", TextFormatter{}, 0},
+		{"JSONShortfile", JSONFormatter{}, Lshortfile | Lmethod},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			l := benchLogger(bench.f, bench.flags)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.InfoC(benchContext, "request served")
+			}
+		})
+	}
+	// the fmt based encoding loggers used before Formatter, for comparison
+	b.Run("JSONLegacy", func(b *testing.B) {
+		l := benchLogger(JSONFormatter{}, 0)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			buffer := getBuffer()
+			legacyFormat(l, buffer, logLine{level: InfoLevel, localCx: benchContext, message: "request served"})
+			io.Discard.Write(buffer.Bytes())
+			putBuffer(buffer)
+		}
+	})
+	b.Run("JSONShortfileLegacy", func(b *testing.B) {
+		l := benchLogger(JSONFormatter{}, Lshortfile|Lmethod)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			buffer := getBuffer()
+			legacyFormat(l, buffer, logLine{level: InfoLevel, localCx: benchContext, message: "request served"})
+			io.Discard.Write(buffer.Bytes())
+			putBuffer(buffer)
+		}
+	})
+}
+
+func BenchmarkInfo(b *testing.B) {
+	l := benchLogger(JSONFormatter{}, 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served")
+	}
+}
+
+func BenchmarkErrorE(b *testing.B) {
+	l := benchLogger(JSONFormatter{}, 0)
+	err := errors.New("backend down")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.ErrorE(err, benchContext, "request failed")
+	}
+}
+
+func BenchmarkInfoCParallel(b *testing.B) {
+	l := benchLogger(JSONFormatter{}, 0)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.InfoC(benchContext, "request served")
+		}
+	})
+}
+
+// legacyFormat is the JSON encoding of Logger.format before Formatter, with
+// its fmt calls for every part of the line
+func legacyFormat(l *Logger, buffer *bytes.Buffer, lline logLine) {
+	var dynamicContext C
+	now := time.Now()
+
+	var flagsFields string
+	if f := atomic.LoadInt32(&l.flags); f&(Llongfile|Lshortfile|Lmethod) != 0 {
+		flagsFields = legacyFlagsInfo(f)
+	}
+
+	fmt.Fprintf(buffer, `{"time":%q, "lvl":%q%s`, now.Format(time.RFC3339), levelNames[lline.level], flagsFields)
+
+	for k, v := range lline.localCx {
+		fmt.Fprintf(buffer, ", %q:%q", k, v)
+	}
+	if contextFunc := l.contextFunc.Load().(func() C); contextFunc != nil {
+		dynamicContext = contextFunc()
+		for k, v := range dynamicContext {
+			if _, already := lline.localCx[k]; !already {
+				fmt.Fprintf(buffer, ", %q:%q", k, v)
+			}
+		}
+	}
+	for k, v := range l.context.Load().(C) {
+		if _, already := lline.localCx[k]; !already {
+			if _, already := dynamicContext[k]; !already {
+				fmt.Fprintf(buffer, ", %q:%q", k, v)
+			}
+		}
+	}
+	if len(lline.params) == 0 {
+		fmt.Fprintf(buffer, `, "msg":%q}`, lline.message)
+	} else {
+		fmt.Fprintf(buffer, `, "msg":%q}`, fmt.Sprintf(lline.message, lline.params...))
+	}
+	fmt.Fprintln(buffer)
+}
+
+func legacyFlagsInfo(flags int32) string {
+	b := getBuffer()
+	defer putBuffer(b)
+
+	pc := make([]uintptr, 10)
+	runtime.Callers(4, pc)
+	frame, _ := runtime.CallersFrames(pc).Next()
+	fileNo := fmt.Sprintf("%s.%d", frame.File, frame.Line)
+
+	if flags&(Llongfile|Lshortfile) != 0 {
+		if flags&Lshortfile != 0 {
+			fileNo = fileNo[strings.LastIndex(fileNo, "/")+1:]
+		}
+		fmt.Fprintf(b, `, "file":%q`, fileNo)
+	}
+	if flags&Lmethod != 0 {
+		fmt.Fprintf(b, `, "func":%q`, frame.Function)
+	}
+	return b.String()
+}
+
+func BenchmarkDebugFnDisabled(b *testing.B) {
+	l := benchLogger(JSONFormatter{}, 0)
+	l.SetLevel(InfoLevel)
+	state := []int{1, 2, 3}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.DebugFn(func() (C, string) { return C{"state": state}, "dump" })
+	}
+}
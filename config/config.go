@@ -0,0 +1,367 @@
+// Package config builds gologops loggers from a declarative document, such as
+//
+//	{
+//	  "loggers": {
+//	    "api": {
+//	      "level": "info",
+//	      "flags": ["shortfile"],
+//	      "context": {"svc": "api"},
+//	      "format": "json",
+//	      "output": "stdout",
+//	      "sinks": [
+//	        {"name": "debug", "level": "debug", "format": "text", "output": "/var/log/api.log"}
+//	      ]
+//	    }
+//	  }
+//	}
+//
+// The level of a logger is the one of its output: above, the debug sink gets
+// the debug lines that stdout does not.
+//
+// JSON files are read out of the box. Other formats need a decoder, for
+// instance for YAML:
+//
+//	config.RegisterDecoder(".yaml", yaml.Unmarshal)
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TDAF/gologops"
+	"github.com/TDAF/gologops/rotate"
+)
+
+// Config is the document describing a set of loggers, by name
+type Config struct {
+	Loggers map[string]LoggerConfig `json:"loggers" yaml:"loggers"`
+}
+
+// LoggerConfig describes a logger. Empty fields keep the defaults of
+// gologops.NewLoggerWithFormatter: every level, no flags, no context, JSON
+// lines to stdout. Level only applies to Output: the logger lets through the
+// lines of the lowest of Level and the levels of Sinks
+type LoggerConfig struct {
+	Level      string       `json:"level" yaml:"level"` // as parsed by gologops.ParseLevel
+	Flags      []string     `json:"flags" yaml:"flags"` // as parsed by gologops.ParseFlags
+	Context    gologops.C   `json:"context" yaml:"context"`
+	Format     string       `json:"format" yaml:"format"` // json or text
+	TimeFormat string       `json:"timeFormat" yaml:"timeFormat"`
+	Output     string       `json:"output" yaml:"output"` // stdout, stderr or the path of a file to append to
+	Sinks      []SinkConfig `json:"sinks" yaml:"sinks"`   // outputs besides Output
+}
+
+// SinkConfig describes a gologops.Sink
+type SinkConfig struct {
+	Name       string `json:"name" yaml:"name"`
+	Level      string `json:"level" yaml:"level"`
+	Format     string `json:"format" yaml:"format"`
+	TimeFormat string `json:"timeFormat" yaml:"timeFormat"`
+	Output     string `json:"output" yaml:"output"`
+}
+
+var (
+	decodersMu sync.Mutex
+	decoders   = map[string]func(data []byte, v interface{}) error{".json": decodeJSON}
+)
+
+// RegisterDecoder makes Load read the files with extension ext, such as
+// ".yaml", with decode
+func RegisterDecoder(ext string, decode func(data []byte, v interface{}) error) {
+	decodersMu.Lock()
+	decoders[strings.ToLower(ext)] = decode
+	decodersMu.Unlock()
+}
+
+// decodeJSON rejects unknown fields, so typos do not go unnoticed
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// Load reads and validates the config file at path, decoded as its extension
+// tells
+func Load(path string) (*Config, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	decodersMu.Lock()
+	decode := decoders[ext]
+	decodersMu.Unlock()
+	if decode == nil {
+		return nil, fmt.Errorf("config: no decoder for %q files, see RegisterDecoder", ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	var c Config
+	if err := decode(data, &c); err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Validate returns every problem of c, one per line, each prefixed with
+// the path of the wrong field, such as loggers.api.sinks[0].level
+func (c *Config) Validate() error {
+	var errs []error
+	if len(c.Loggers) == 0 {
+		errs = append(errs, errors.New("loggers: no logger defined"))
+	}
+	for _, name := range sortedNames(c.Loggers) {
+		lc := c.Loggers[name]
+		field := "loggers." + name
+		if name == "" {
+			errs = append(errs, errors.New("loggers: empty logger name"))
+		}
+		errs = append(errs, validateLevel(field, lc.Level), validateFormat(field, lc.Format))
+		for _, flag := range lc.Flags {
+			if _, err := gologops.ParseFlags(flag); err != nil {
+				errs = append(errs, fmt.Errorf("%s.flags: %w", field, err))
+			}
+		}
+		sinkNames := map[string]bool{}
+		for i, sc := range lc.Sinks {
+			sinkField := fmt.Sprintf("%s.sinks[%d]", field, i)
+			errs = append(errs, validateLevel(sinkField, sc.Level), validateFormat(sinkField, sc.Format))
+			if sc.Output == "" {
+				errs = append(errs, fmt.Errorf("%s.output: missing", sinkField))
+			}
+			if sc.Name != "" && sinkNames[sc.Name] {
+				errs = append(errs, fmt.Errorf("%s.name: %q used by another sink", sinkField, sc.Name))
+			}
+			sinkNames[sc.Name] = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateLevel(field, level string) error {
+	if level == "" {
+		return nil
+	}
+	if _, err := gologops.ParseLevel(level); err != nil {
+		return fmt.Errorf("%s.level: unknown level %q", field, level)
+	}
+	return nil
+}
+
+func validateFormat(field, format string) error {
+	switch strings.ToLower(format) {
+	case "", "json", "text":
+		return nil
+	}
+	return fmt.Errorf("%s.format: unknown format %q, want json or text", field, format)
+}
+
+// Loggers are the loggers built from a Config, by name
+type Loggers struct {
+	loggers    map[string]*gologops.Logger
+	sinkLevels map[string]gologops.Level // lowest level of the sinks of each logger
+	files      map[string]*rotate.File   // outputs, by path
+
+	path string // of the config file, for Watch
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open loads the config file at path and builds its loggers
+func Open(path string) (*Loggers, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	ls, err := Build(c)
+	if err != nil {
+		return nil, err
+	}
+	ls.path = path
+	return ls, nil
+}
+
+// Build validates c and builds its loggers. Files in outputs are opened
+// once, even if several loggers or sinks write to them
+func Build(c *Config) (*Loggers, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	ls := &Loggers{
+		loggers:    map[string]*gologops.Logger{},
+		sinkLevels: map[string]gologops.Level{},
+		files:      map[string]*rotate.File{},
+	}
+	for name, lc := range c.Loggers {
+		w, err := ls.output(lc.Output)
+		if err != nil {
+			ls.Close()
+			return nil, fmt.Errorf("config: loggers.%s.output: %w", name, err)
+		}
+		l := gologops.NewLoggerWithFormatter(w, formatter(lc.Format, lc.TimeFormat))
+		var flags int32
+		for _, flag := range lc.Flags {
+			f, _ := gologops.ParseFlags(flag)
+			flags |= f
+		}
+		l.SetFlags(flags)
+		l.SetContext(lc.Context)
+		sinkLevel := gologops.NoneLevel
+		for i, sc := range lc.Sinks {
+			w, err := ls.output(sc.Output)
+			if err != nil {
+				ls.Close()
+				return nil, fmt.Errorf("config: loggers.%s.sinks[%d].output: %w", name, i, err)
+			}
+			lvl, _ := gologops.ParseLevel(sc.Level)
+			l.AddSink(gologops.Sink{
+				Name:      sc.Name,
+				Writer:    w,
+				Formatter: formatter(sc.Format, sc.TimeFormat),
+				Level:     lvl, // AllLevel if empty
+			})
+			sinkLevel = min(sinkLevel, lvl)
+		}
+		ls.loggers[name] = l
+		ls.sinkLevels[name] = sinkLevel
+		ls.setLevel(name, lc.Level)
+	}
+	return ls, nil
+}
+
+func (ls *Loggers) output(output string) (io.Writer, error) {
+	switch output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	if f := ls.files[output]; f != nil {
+		return f, nil
+	}
+	f, err := rotate.Open(output, rotate.Options{})
+	if err != nil {
+		return nil, err
+	}
+	ls.files[output] = f
+	return f, nil
+}
+
+func formatter(format, timeFormat string) gologops.Formatter {
+	if strings.ToLower(format) == "text" {
+		return gologops.TextFormatter{TimeFormat: timeFormat}
+	}
+	return gologops.JSONFormatter{TimeFormat: timeFormat}
+}
+
+// Get returns the logger called name, or nil if there is none
+func (ls *Loggers) Get(name string) *gologops.Logger {
+	return ls.loggers[name]
+}
+
+// Names returns the names of the loggers, sorted
+func (ls *Loggers) Names() []string {
+	return sortedNames(ls.loggers)
+}
+
+// DefaultWatchInterval is used by Watch for intervals of zero or less
+const DefaultWatchInterval = 10 * time.Second
+
+// Watch checks the config file every interval and, when it changes, sets the
+// levels of the loggers, those of their Output, to the new ones. Sink levels are fixed once the sink
+// is added, so they, like any other change and loggers added or removed, need
+// a new Build. A file that cannot be loaded is
+// reported to onError, if not nil, and leaves levels untouched. Watch only
+// works for Loggers returned by Open
+func (ls *Loggers) Watch(interval time.Duration, onError func(error)) {
+	if ls.path == "" || ls.stop != nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ls.stop = make(chan struct{})
+	ls.done = make(chan struct{})
+	last, _ := os.Stat(ls.path)
+	go func() {
+		defer close(ls.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ls.stop:
+				return
+			case <-ticker.C:
+			}
+			info, err := os.Stat(ls.path)
+			if err != nil || (last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+				continue
+			}
+			last = info
+			if err := ls.reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+}
+
+func (ls *Loggers) reload() error {
+	c, err := Load(ls.path)
+	if err != nil {
+		return err
+	}
+	for name, lc := range c.Loggers {
+		if ls.loggers[name] == nil {
+			continue
+		}
+		ls.setLevel(name, lc.Level)
+	}
+	return nil
+}
+
+// setLevel sets the level of the first sink of the logger called name, and
+// lets through the lines of that level or of any of its other sinks
+func (ls *Loggers) setLevel(name, level string) {
+	lvl := gologops.AllLevel
+	if level != "" {
+		lvl, _ = gologops.ParseLevel(level)
+	}
+	l := ls.loggers[name]
+	l.SetSinkLevel(lvl)
+	l.SetLevel(min(lvl, ls.sinkLevels[name]))
+}
+
+// Close stops watching the config file and closes the files written by the
+// loggers
+func (ls *Loggers) Close() error {
+	if ls.stop != nil {
+		close(ls.stop)
+		<-ls.done
+		ls.stop = nil
+	}
+	var errs []error
+	for _, f := range ls.files {
+		errs = append(errs, f.Close())
+	}
+	ls.files = nil
+	return errors.Join(errs...)
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TDAF/gologops"
+)
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "api.log")
+	debug := filepath.Join(dir, "debug.log")
+	path := filepath.Join(dir, "logging.json")
+	writeConfig(t, path, `{"loggers": {
+		"api": {
+			"level": "info", "flags": ["shortfile"], "context": {"svc": "api", "shard": 2},
+			"output": "`+out+`",
+			"sinks": [{"name": "debug", "level": "debug", "format": "text", "timeFormat": "static", "output": "`+debug+`"}]
+		},
+		"db": {"level": "error", "output": "`+out+`"}
+	}}`)
+
+	ls, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ls.Names(), ","); got != "api,db" {
+		t.Errorf("names: want api,db, got %s", got)
+	}
+	if ls.Get("missing") != nil {
+		t.Errorf("unknown logger returned")
+	}
+	ls.Get("api").Debug("api debug")
+	ls.Get("api").Info("api line")
+	ls.Get("db").Warn("filtered")
+	ls.Get("db").Error("db line")
+	if err := ls.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %q", lines)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj["msg"] != "api line" || obj["svc"] != "api" || obj["shard"] != 2.0 ||
+		!strings.HasPrefix(obj["file"].(string), "config_test.go.") {
+		t.Errorf("unexpected api line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `"msg":"db line"`) {
+		t.Errorf("unexpected db line %q", lines[1])
+	}
+
+	b, err = os.ReadFile(debug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "static DEBUG config_test.go.") ||
+		!strings.HasSuffix(lines[0], "api debug") || !strings.HasSuffix(lines[1], "api line") {
+		t.Errorf("sink should get the lines of its level, below the one of the logger: %q", lines)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Config{Loggers: map[string]LoggerConfig{
+		"api": {
+			Level:  "verbose",
+			Flags:  []string{"color"},
+			Format: "xml",
+			Sinks:  []SinkConfig{{Name: "a", Output: "stderr"}, {Name: "a", Level: "loud"}},
+		},
+	}}
+	err := c.Validate()
+	want := []string{
+		`loggers.api.level: unknown level "verbose"`,
+		`loggers.api.format: unknown format "xml", want json or text`,
+		`loggers.api.flags: unknown flag "color"`,
+		`loggers.api.sinks[1].level: unknown level "loud"`,
+		`loggers.api.sinks[1].output: missing`,
+		`loggers.api.sinks[1].name: "a" used by another sink`,
+	}
+	if err == nil || err.Error() != strings.Join(want, "\n") {
+		t.Errorf("want\n%s\ngot\n%v", strings.Join(want, "\n"), err)
+	}
+	if _, err := Build(&c); err == nil {
+		t.Errorf("invalid config built")
+	}
+	if err := (&Config{}).Validate(); err == nil {
+		t.Errorf("config without loggers accepted")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logging.json")
+	writeConfig(t, path, `{"loggers": {"api": {"levle": "info"}}}`)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), `unknown field "levle"`) {
+		t.Errorf("unknown field: %v", err)
+	}
+
+	yaml := filepath.Join(dir, "logging.yaml")
+	writeConfig(t, yaml, "loggers:\n  api:\n    level: warn\n")
+	if _, err := Load(yaml); err == nil || !strings.Contains(err.Error(), "RegisterDecoder") {
+		t.Errorf("missing decoder: %v", err)
+	}
+	RegisterDecoder(".yaml", func(data []byte, v interface{}) error {
+		if !strings.Contains(string(data), "level: warn") {
+			return errors.New("unexpected document")
+		}
+		*v.(*Config) = Config{Loggers: map[string]LoggerConfig{"api": {Level: "warn"}}}
+		return nil
+	})
+	defer func() {
+		decodersMu.Lock()
+		delete(decoders, ".yaml")
+		decodersMu.Unlock()
+	}()
+	if c, err := Load(yaml); err != nil || c.Loggers["api"].Level != "warn" {
+		t.Errorf("registered decoder: %v, %v", c, err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logging.json")
+	writeConfig(t, path, `{"loggers": {"api": {"level": "error", "output": "stderr",
+		"sinks": [{"level": "warn", "output": "stderr"}]}}}`)
+	ls, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ls.Close()
+	errs := make(chan error, 10)
+	ls.Watch(5*time.Millisecond, func(err error) { errs <- err })
+
+	l := ls.Get("api")
+	if l.Enabled(gologops.InfoLevel) || !l.Enabled(gologops.WarnLevel) {
+		t.Fatalf("level not set to the one of the sink")
+	}
+
+	mtime := time.Now()
+	change := func(content string) {
+		writeConfig(t, path, content)
+		mtime = mtime.Add(time.Second)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	change(`{"loggers": {"api": {"level": "invalid"}}}`)
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "loggers.api.level") {
+			t.Errorf("unexpected error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("invalid config not reported")
+	}
+
+	change(`{"loggers": {"api": {"level": "debug", "output": "stderr"}, "new": {}}}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for !l.Enabled(gologops.DebugLevel) {
+		if time.Now().After(deadline) {
+			t.Fatalf("level not reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if ls.Get("new") != nil {
+		t.Errorf("loggers should not be added on reload")
+	}
+
+	change(`{"loggers": {"api": {"level": "critical", "output": "stderr"}}}`)
+	deadline = time.Now().Add(5 * time.Second)
+	for l.Enabled(gologops.InfoLevel) {
+		if time.Now().After(deadline) {
+			t.Fatalf("level not reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !l.Enabled(gologops.WarnLevel) {
+		t.Errorf("reload should keep the lines of the sinks")
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.json")
+	writeConfig(t, path, `{"loggers": {"api": {"output": "stderr"}}}`)
+	ls, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls.Watch(0, nil) // must not panic the watching goroutine
+	time.Sleep(10 * time.Millisecond)
+	if err := ls.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
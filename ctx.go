@@ -0,0 +1,91 @@
+package gologops
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying c, on top of any context already
+// carried by ctx. It is written by the Ctx logging methods
+func NewContext(ctx context.Context, c C) context.Context {
+	previous := FromContext(ctx)
+	if len(previous) == 0 {
+		return context.WithValue(ctx, contextKey{}, c)
+	}
+	merged := make(C, len(previous)+len(c))
+	for k, v := range previous {
+		merged[k] = v
+	}
+	for k, v := range c {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// FromContext returns the context carried by ctx, nil if there is none
+func FromContext(ctx context.Context) C {
+	c, _ := ctx.Value(contextKey{}).(C)
+	return c
+}
+
+// DebugCtx logs like DebugC, adding the context carried by ctx. Local
+// context takes precedence over it, and it over dynamic and logger context.
+// So do the other Ctx methods
+func (l *Logger) DebugCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	l.LogC(logLine{level: DebugLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func (l *Logger) InfoCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	l.LogC(logLine{level: InfoLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func (l *Logger) WarnCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	l.LogC(logLine{level: WarnLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func (l *Logger) ErrorECtx(ctx context.Context, err error, cx C, message string, params ...interface{}) {
+	l.LogC(logLine{err: err, level: ErrorLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func (l *Logger) ErrorCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	l.LogC(logLine{level: ErrorLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func (l *Logger) FatalECtx(ctx context.Context, err error, cx C, message string, params ...interface{}) {
+	l.LogC(logLine{err: err, level: CriticalLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+	l.exit()
+}
+
+func (l *Logger) FatalCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	l.LogC(logLine{level: CriticalLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+	l.exit()
+}
+
+func DebugCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{level: DebugLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func InfoCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{level: InfoLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func WarnCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{level: WarnLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func ErrorECtx(ctx context.Context, err error, cx C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{err: err, level: ErrorLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func ErrorCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{level: ErrorLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+}
+
+func FatalECtx(ctx context.Context, err error, cx C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{err: err, level: CriticalLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+	defaultLogger.exit()
+}
+
+func FatalCtx(ctx context.Context, cx C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{level: CriticalLevel, localCx: cx, requestCx: FromContext(ctx), message: message, params: params})
+	defaultLogger.exit()
+}
@@ -0,0 +1,83 @@
+package gologops
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestNewContext(t *testing.T) {
+	ctx := context.Background()
+	if c := FromContext(ctx); c != nil {
+		t.Errorf("empty context.Context should carry no context, got %v", c)
+	}
+	ctx1 := NewContext(ctx, C{"corr": "1", "op": "a"})
+	ctx2 := NewContext(ctx1, C{"op": "b"})
+	if want, got := (C{"corr": "1", "op": "a"}), FromContext(ctx1); !reflect.DeepEqual(want, got) {
+		t.Errorf("parent context.Context: want %v, got %v", want, got)
+	}
+	if want, got := (C{"corr": "1", "op": "b"}), FromContext(ctx2); !reflect.DeepEqual(want, got) {
+		t.Errorf("child context.Context: want %v, got %v", want, got)
+	}
+}
+
+func TestCtxPrecedence(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetContext(C{"a": "logger", "b": "logger", "c": "logger", "d": "logger"})
+	l.SetContextFunc(func() C { return C{"a": "func", "b": "func", "c": "func"} })
+	ctx := NewContext(context.Background(), C{"a": "ctx", "b": "ctx"})
+
+	l.InfoCtx(ctx, C{"a": "local"}, "msg %d", 1)
+	want := map[string]interface{}{
+		"lvl": "INFO", "msg": "msg 1", "a": "local", "b": "ctx", "c": "func", "d": "logger",
+	}
+	if got := decodeLine(t, &buffer); !reflect.DeepEqual(want, got) {
+		t.Errorf("line: want %v, got %v", want, got)
+	}
+}
+
+var levelCtxFunc = map[Level]func(l *Logger, ctx context.Context, cx C, message string, params ...interface{}){
+	DebugLevel:    (*Logger).DebugCtx,
+	InfoLevel:     (*Logger).InfoCtx,
+	WarnLevel:     (*Logger).WarnCtx,
+	ErrorLevel:    (*Logger).ErrorCtx,
+	CriticalLevel: (*Logger).FatalCtx,
+}
+
+func TestLevelsCtxFunction(t *testing.T) {
+	ctx := NewContext(context.Background(), C{"corr": "123"})
+	for level, method := range levelCtxFunc {
+		testLevelC(t, level, func(l *Logger, cx C, message string, params ...interface{}) {
+			method(l, ctx, cx, message, params...)
+		})
+	}
+	testLevelE(t, ErrorLevel, func(l *Logger, err error, cx C, message string, params ...interface{}) {
+		l.ErrorECtx(ctx, err, cx, message, params...)
+	})
+	testLevelE(t, CriticalLevel, func(l *Logger, err error, cx C, message string, params ...interface{}) {
+		l.FatalECtx(ctx, err, cx, message, params...)
+	})
+}
+
+func TestGlobalCtx(t *testing.T) {
+	var buffer bytes.Buffer
+	defer SetWriter(defaultLogger.sinkList()[0].writer)
+	SetWriter(&buffer)
+	WarnCtx(NewContext(context.Background(), C{"corr": "123"}), nil, "msg")
+	if obj := decodeLine(t, &buffer); obj["corr"] != "123" {
+		t.Errorf("missing context from context.Context in %v", obj)
+	}
+}
+
+func TestSlogHandlerCtx(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	ctx := NewContext(context.Background(), C{"corr": "123"})
+	slog.New(NewSlogHandler(l)).InfoContext(ctx, "msg")
+	if obj := decodeLine(t, &buffer); obj["corr"] != "123" {
+		t.Errorf("missing context from context.Context in %v", obj)
+	}
+}
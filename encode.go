@@ -0,0 +1,302 @@
+package gologops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString writes s as a quoted JSON string (RFC 8259). Control
+// characters are escaped, as are U+2028 and U+2029 for the sake of JavaScript
+// readers, and invalid UTF-8 is replaced by U+FFFD
+func writeJSONString(buffer *bytes.Buffer, s string) {
+	buffer.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buffer.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buffer.WriteByte('\\')
+				buffer.WriteByte(c)
+			case '\n':
+				buffer.WriteString(`\n`)
+			case '\r':
+				buffer.WriteString(`\r`)
+			case '\t':
+				buffer.WriteString(`\t`)
+			case '\b':
+				buffer.WriteString(`\b`)
+			case '\f':
+				buffer.WriteString(`\f`)
+			default:
+				buffer.WriteString(`\u00`)
+				buffer.WriteByte(hexDigits[c>>4])
+				buffer.WriteByte(hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buffer.WriteString(s[start:i])
+			buffer.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buffer.WriteString(s[start:i])
+			buffer.WriteString(`\u202`)
+			buffer.WriteByte(hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buffer.WriteString(s[start:])
+	buffer.WriteByte('"')
+}
+
+// cachedTime is a time already written as a JSON string in RFC 3339, that
+// only changes once per second
+type cachedTime struct {
+	unix int64
+	loc  *time.Location
+	json string
+}
+
+var lastJSONTime atomic.Pointer[cachedTime]
+
+// writeJSONTime writes t in layout as a JSON string, with no allocation for
+// RFC 3339, the default, nor for layouts not needing escapes
+func writeJSONTime(buffer *bytes.Buffer, t time.Time, layout string) {
+	if layout == time.RFC3339 {
+		if c := lastJSONTime.Load(); c != nil && c.unix == t.Unix() && c.loc == t.Location() {
+			buffer.WriteString(c.json)
+			return
+		}
+		start := buffer.Len()
+		writeJSONString(buffer, t.Format(layout))
+		lastJSONTime.Store(&cachedTime{unix: t.Unix(), loc: t.Location(), json: string(buffer.Bytes()[start:])})
+		return
+	}
+	start := buffer.Len()
+	buffer.WriteByte('"')
+	buffer.Write(t.AppendFormat(buffer.AvailableBuffer(), layout))
+	for _, c := range buffer.Bytes()[start+1:] {
+		if c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' {
+			buffer.Truncate(start)
+			writeJSONString(buffer, t.Format(layout))
+			return
+		}
+	}
+	buffer.WriteByte('"')
+}
+
+// writeJSONValue writes v as JSON: numbers and booleans as such, durations as
+// nanoseconds, times as RFC 3339 strings, errors as their message, or null
+// for nil pointers, and C as nested objects. Anything that cannot be
+// marshaled is written as a string
+func writeJSONValue(buffer *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case string:
+		writeJSONString(buffer, v)
+	case bool:
+		buffer.Write(strconv.AppendBool(buffer.AvailableBuffer(), v))
+	case int:
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), int64(v), 10))
+	case int8:
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), int64(v), 10))
+	case int16:
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), int64(v), 10))
+	case int32:
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), int64(v), 10))
+	case int64:
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), v, 10))
+	case uint:
+		buffer.Write(strconv.AppendUint(buffer.AvailableBuffer(), uint64(v), 10))
+	case uint8:
+		buffer.Write(strconv.AppendUint(buffer.AvailableBuffer(), uint64(v), 10))
+	case uint16:
+		buffer.Write(strconv.AppendUint(buffer.AvailableBuffer(), uint64(v), 10))
+	case uint32:
+		buffer.Write(strconv.AppendUint(buffer.AvailableBuffer(), uint64(v), 10))
+	case uint64:
+		buffer.Write(strconv.AppendUint(buffer.AvailableBuffer(), v, 10))
+	case float32:
+		writeJSONFloat(buffer, float64(v), 32)
+	case float64:
+		writeJSONFloat(buffer, v, 64)
+	case time.Duration:
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), int64(v), 10))
+	case time.Time:
+		writeJSONString(buffer, v.Format(time.RFC3339Nano))
+	case []string:
+		buffer.WriteByte('[')
+		for i, s := range v {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeJSONString(buffer, s)
+		}
+		buffer.WriteByte(']')
+	case C:
+		writeJSONObject(buffer, v)
+	case map[string]interface{}:
+		writeJSONObject(buffer, v)
+	case Stack:
+		buffer.WriteByte('[')
+		for i, frame := range v {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteString(`{"func":`)
+			writeJSONString(buffer, frame.Func)
+			buffer.WriteString(`,"file":`)
+			writeJSONString(buffer, frame.File)
+			buffer.WriteString(`,"line":`)
+			buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), int64(frame.Line), 10))
+			buffer.WriteByte('}')
+		}
+		buffer.WriteByte(']')
+	case error:
+		if isNilPointer(v) {
+			buffer.WriteString("null")
+			return
+		}
+		writeJSONString(buffer, v.Error())
+	default:
+		writeJSONMarshal(buffer, v)
+	}
+}
+
+// writeJSONFloat writes f as a JSON number, or as a string for NaN and infinities
+func writeJSONFloat(buffer *bytes.Buffer, f float64, bitSize int) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		writeJSONString(buffer, strconv.FormatFloat(f, 'g', -1, bitSize))
+		return
+	}
+	buffer.Write(strconv.AppendFloat(buffer.AvailableBuffer(), f, 'g', -1, bitSize))
+}
+
+// writeJSONObject writes m as a JSON object with its keys sorted
+func writeJSONObject(buffer *bytes.Buffer, m map[string]interface{}) {
+	buffer.WriteByte('{')
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		writeJSONString(buffer, k)
+		buffer.WriteByte(':')
+		writeJSONValue(buffer, m[k])
+	}
+	buffer.WriteByte('}')
+}
+
+// writeJSONMarshal writes v using encoding/json, json.Marshaler included.
+// If v cannot be marshaled its fmt representation is written as a string
+func writeJSONMarshal(buffer *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeJSONString(buffer, fmt.Sprintf("%+v (%s)", v, err))
+		return
+	}
+	buffer.Write(b)
+}
+
+// TextValue returns v as written by TextFormatter
+func TextValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b := getBuffer()
+	defer putBuffer(b)
+	writeTextValue(b, v)
+	return b.String()
+}
+
+// writeTextValue writes v for human eyes: durations as "1.5s", times as
+// RFC 3339, errors as their message, C as "{k=v k2=v2}" and JSON marshalers
+// as their JSON. Nil pointers are written as "<nil>"
+func writeTextValue(buffer *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		buffer.WriteString(v)
+	case int:
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), int64(v), 10))
+	case int64:
+		buffer.Write(strconv.AppendInt(buffer.AvailableBuffer(), v, 10))
+	case bool:
+		buffer.Write(strconv.AppendBool(buffer.AvailableBuffer(), v))
+	case time.Time:
+		buffer.Write(v.AppendFormat(buffer.AvailableBuffer(), time.RFC3339Nano))
+	case C:
+		writeTextObject(buffer, v)
+	case map[string]interface{}:
+		writeTextObject(buffer, v)
+	case error:
+		if isNilPointer(v) {
+			buffer.WriteString("<nil>")
+			return
+		}
+		buffer.WriteString(v.Error())
+	case fmt.Stringer:
+		if isNilPointer(v) {
+			buffer.WriteString("<nil>")
+			return
+		}
+		buffer.WriteString(v.String())
+	case json.Marshaler:
+		writeJSONMarshal(buffer, v)
+	default:
+		fmt.Fprintf(buffer, "%+v", v)
+	}
+}
+
+// isNilPointer tells whether v holds a nil pointer, whose Error or String
+// methods would most likely panic
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// writeTextObject writes m as "{k=v k2=v2}" with its keys sorted
+func writeTextObject(buffer *bytes.Buffer, m map[string]interface{}) {
+	buffer.WriteByte('{')
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			buffer.WriteByte(' ')
+		}
+		buffer.WriteString(k)
+		buffer.WriteByte('=')
+		writeTextValue(buffer, m[k])
+	}
+	buffer.WriteByte('}')
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,218 @@
+package gologops
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var jsonStringTests = []struct {
+	in, out string
+}{
+	{"", `""`},
+	{"España y olé", `"España y olé"`},
+	{`She said: "yes\no"`, `"She said: \"yes\\no\""`},
+	{"\x00\a\b\t\n\f\r\x1f", `"\u0000\u0007\b\t\n\f\r\u001f"`},
+	{"\x7f<&>", "\"\x7f<&>\""},
+	{"bad \xff utf-8 \xc3", `"bad \ufffd utf-8 \ufffd"`},
+	{"\U0001F600", "\"\U0001F600\""},
+	{"\u2028\u2029", `"\u2028\u2029"`},
+}
+
+func TestWriteJSONString(t *testing.T) {
+	var buffer bytes.Buffer
+	for _, test := range jsonStringTests {
+		buffer.Reset()
+		writeJSONString(&buffer, test.in)
+		if got := buffer.String(); got != test.out {
+			t.Errorf("JSON string for %q: want %s, got %s", test.in, test.out, got)
+		}
+	}
+}
+
+// jsonMembers returns the keys and values of a JSON object with only string
+// members, in the order they were written
+func jsonMembers(line []byte) ([]string, error) {
+	var members []string
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("not an object: %v %v", tok, err)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		str, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("not a string: %v", tok)
+		}
+		members = append(members, str)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after object: %v", err)
+	}
+	return members, nil
+}
+
+// asDecoded is s as returned by a JSON decoder, every invalid byte replaced by U+FFFD
+func asDecoded(s string) string {
+	return string([]rune(s))
+}
+
+func FuzzJSONFormatter(f *testing.F) {
+	for _, s := range stringsForTesting {
+		f.Add(s, s, s, s, s)
+	}
+	for _, test := range jsonStringTests {
+		f.Add(test.in, "key", test.in, "logger.go.12", test.in)
+	}
+	f.Fuzz(func(t *testing.T, msg, key, value, file, function string) {
+		var buffer bytes.Buffer
+		e := Entry{
+			Time:    time.Now(),
+			Level:   InfoLevel,
+			File:    file,
+			Func:    function,
+			Fields:  []Field{{key, value}},
+			Message: msg,
+		}
+		JSONFormatter{}.Format(&buffer, &e)
+		line := buffer.Bytes()
+		if !json.Valid(line) {
+			t.Fatalf("invalid JSON: %q", line)
+		}
+		members, err := jsonMembers(line)
+		if err != nil {
+			t.Fatalf("%v in %q", err, line)
+		}
+		var want []string
+		want = append(want, "time", e.Time.Format(jsonTimeFormat), "lvl", "INFO")
+		if file != "" {
+			want = append(want, "file", asDecoded(file))
+		}
+		if function != "" {
+			want = append(want, "func", asDecoded(function))
+		}
+		want = append(want, asDecoded(key), asDecoded(value), "msg", asDecoded(msg))
+		if fmt.Sprintf("%q", members) != fmt.Sprintf("%q", want) {
+			t.Errorf("members: want %q, got %q", want, members)
+		}
+	})
+}
+
+func FuzzFormatError(f *testing.F) {
+	for _, test := range jsonStringTests {
+		f.Add(test.in)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		for _, err := range []error{errors.New(text), testingNotJSONableNError{}} {
+			var buffer bytes.Buffer
+			l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+			l.ErrorE(err, C{text: text}, text)
+			if !json.Valid(buffer.Bytes()) {
+				t.Fatalf("invalid JSON: %q", buffer.Bytes())
+			}
+		}
+	})
+}
+
+type testingMarshaler struct{}
+
+func (testingMarshaler) MarshalJSON() ([]byte, error) { return []byte(`{"custom": [1, 2]}`), nil }
+
+type testingPtrErr struct{ msg string }
+
+func (e *testingPtrErr) Error() string { return e.msg }
+
+type testingPtrStringer struct{ s string }
+
+func (s *testingPtrStringer) String() string { return s.s }
+
+var typedValueTests = []struct {
+	value      interface{}
+	json, text string
+}{
+	{"olé", `"olé"`, "olé"},
+	{42, `42`, "42"},
+	{int64(-7), `-7`, "-7"},
+	{uint8(255), `255`, "255"},
+	{1.5, `1.5`, "1.5"},
+	{float32(0.25), `0.25`, "0.25"},
+	{math.Inf(1), `"+Inf"`, "+Inf"},
+	{true, `true`, "true"},
+	{1500 * time.Millisecond, `1500000000`, "1.5s"},
+	{time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC), `"2016-01-02T03:04:05Z"`, "2016-01-02T03:04:05Z"},
+	{[]string{"a", `"b"`}, `["a","\"b\""]`, `[a "b"]`},
+	{C{"b": 2, "a": C{"c": "d"}}, `{"a":{"c":"d"},"b":2}`, "{a={c=d} b=2}"},
+	{map[string]interface{}{"x": false}, `{"x":false}`, "{x=false}"},
+	{testingMarshaler{}, `{"custom":[1,2]}`, `{"custom":[1,2]}`},
+	{errors.New("boom"), `"boom"`, "boom"},
+	{nil, `null`, "<nil>"},
+	{(*testingPtrErr)(nil), `null`, "<nil>"},
+	{(*testingPtrStringer)(nil), `null`, "<nil>"},
+	{&testingPtrStringer{"set"}, `{}`, "set"},
+	{struct{ A int }{3}, `{"A":3}`, "{A:3}"},
+}
+
+func TestTypedValues(t *testing.T) {
+	var buffer bytes.Buffer
+	for _, test := range typedValueTests {
+		buffer.Reset()
+		writeJSONValue(&buffer, test.value)
+		if got := buffer.String(); got != test.json {
+			t.Errorf("JSON for %#v: want %s, got %s", test.value, test.json, got)
+		}
+		if !json.Valid(buffer.Bytes()) {
+			t.Errorf("invalid JSON for %#v: %s", test.value, buffer.Bytes())
+		}
+		buffer.Reset()
+		writeTextValue(&buffer, test.value)
+		if got := buffer.String(); got != test.text {
+			t.Errorf("text for %#v: want %s, got %s", test.value, test.text, got)
+		}
+	}
+}
+
+func TestTypedContext(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetContext(C{"retries": 3})
+	l.InfoC(C{"latency": 2 * time.Second, "ok": true, "tags": []string{"x"}}, "typed")
+
+	var obj struct {
+		Retries int
+		Latency time.Duration
+		OK      bool
+		Tags    []string
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &obj); err != nil {
+		t.Fatalf("%v in %s", err, buffer.Bytes())
+	}
+	if obj.Retries != 3 || obj.Latency != 2*time.Second || !obj.OK || len(obj.Tags) != 1 {
+		t.Errorf("typed fields not preserved: %s", buffer.Bytes())
+	}
+}
+
+func TestNilPointerContext(t *testing.T) {
+	for f, want := range map[Formatter]string{
+		JSONFormatter{}: `"cause":null, "who":null`,
+		TextFormatter{}: "[cause=<nil>] [who=<nil>]",
+	} {
+		var buffer bytes.Buffer
+		l := NewLoggerWithFormatter(&buffer, f)
+		l.InfoC(C{"cause": (*testingPtrErr)(nil), "who": (*testingPtrStringer)(nil)}, "x")
+		if got := buffer.String(); !strings.Contains(got, want) {
+			t.Errorf("%T: want %s in %s", f, want, got)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package gologops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeLayouts are the names of time layouts accepted in LOGOPS_TIME_FORMAT
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339NANO": time.RFC3339Nano,
+	"DATETIME":    time.DateTime,
+	"STAMPMILLI":  time.StampMilli,
+	"KITCHEN":     time.Kitchen,
+}
+
+// formatterFromEnv returns TextFormatter if LOGOPS_FORMAT is "dev" or "text"
+// and JSONFormatter otherwise, with the layout of LOGOPS_TIME_FORMAT
+func formatterFromEnv() Formatter {
+	timeFormat := os.Getenv("LOGOPS_TIME_FORMAT")
+	if layout, ok := timeLayouts[strings.ToUpper(timeFormat)]; ok {
+		timeFormat = layout
+	}
+	switch strings.ToLower(os.Getenv("LOGOPS_FORMAT")) {
+	case "dev", "text":
+		return TextFormatter{TimeFormat: timeFormat}
+	default:
+		return JSONFormatter{TimeFormat: timeFormat}
+	}
+}
+
+// ConfigureFromEnv sets l from the LOGOPS_* environment variables that are
+// set, as NewLogger does:
+//
+//	LOGOPS_FORMAT       "dev" or "text" for TextFormatter, "json" for JSONFormatter
+//	LOGOPS_TIME_FORMAT  a time.Format layout, or RFC3339, RFC3339Nano, DateTime, StampMilli or Kitchen
+//	LOGOPS_LEVEL        a level as parsed by ParseLevel
+//	LOGOPS_FLAGS        comma separated longfile (or file), shortfile, method and stack
+//	LOGOPS_OUTPUT       stdout, stderr or the path of a file to append to
+//	LOGOPS_CONTEXT      comma separated key=value pairs, or a JSON object
+//
+// Variables with invalid values are skipped and reported in the returned
+// error. A file in LOGOPS_OUTPUT is opened once, shared by every logger
+// writing to it, and stays open for the life of the process
+func (l *Logger) ConfigureFromEnv() error {
+	var errs []error
+	if format, ok := os.LookupEnv("LOGOPS_FORMAT"); ok {
+		switch strings.ToLower(format) {
+		case "dev", "text", "json":
+			l.SetFormatter(formatterFromEnv())
+		default:
+			errs = append(errs, fmt.Errorf("LOGOPS_FORMAT: unknown format %q", format))
+		}
+	} else if _, ok := os.LookupEnv("LOGOPS_TIME_FORMAT"); ok {
+		l.SetFormatter(formatterFromEnv())
+	}
+	if s, ok := os.LookupEnv("LOGOPS_LEVEL"); ok {
+		if lvl, err := ParseLevel(s); err != nil {
+			errs = append(errs, fmt.Errorf("LOGOPS_LEVEL: %w", err))
+		} else {
+			l.SetLevel(lvl)
+		}
+	}
+	if s, ok := os.LookupEnv("LOGOPS_FLAGS"); ok {
+		if flags, err := ParseFlags(s); err != nil {
+			errs = append(errs, fmt.Errorf("LOGOPS_FLAGS: %w", err))
+		} else {
+			l.SetFlags(flags)
+		}
+	}
+	if s, ok := os.LookupEnv("LOGOPS_OUTPUT"); ok {
+		if w, err := openOutput(s); err != nil {
+			errs = append(errs, fmt.Errorf("LOGOPS_OUTPUT: %w", err))
+		} else {
+			l.SetWriter(w)
+		}
+	}
+	if s, ok := os.LookupEnv("LOGOPS_CONTEXT"); ok {
+		if c, err := parseContext(s); err != nil {
+			errs = append(errs, fmt.Errorf("LOGOPS_CONTEXT: %w", err))
+		} else {
+			l.SetContext(c)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var flagNames = map[string]int32{
+	"longfile":  Llongfile,
+	"file":      Llongfile,
+	"shortfile": Lshortfile,
+	"method":    Lmethod,
+	"stack":     Lstack,
+}
+
+// ParseFlags returns the flags named in s, separated by commas: longfile (or
+// file), shortfile, method and stack, in any case
+func ParseFlags(s string) (int32, error) {
+	var flags int32
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		flag, ok := flagNames[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown flag %q", name)
+		}
+		flags |= flag
+	}
+	return flags, nil
+}
+
+var (
+	outputFilesMu sync.Mutex
+	outputFiles   = map[string]*os.File{}
+)
+
+// openOutput returns the writer of LOGOPS_OUTPUT. A file is opened once and
+// shared by every logger configured from the environment
+func openOutput(s string) (io.Writer, error) {
+	switch s {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	case "":
+		return nil, errors.New("empty output")
+	}
+	outputFilesMu.Lock()
+	defer outputFilesMu.Unlock()
+	if f := outputFiles[s]; f != nil {
+		return f, nil
+	}
+	f, err := os.OpenFile(s, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	outputFiles[s] = f
+	return f, nil
+}
+
+func parseContext(s string) (C, error) {
+	c := C{}
+	if strings.HasPrefix(strings.TrimSpace(s), "{") {
+		if err := json.Unmarshal([]byte(s), &c); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if k = strings.TrimSpace(k); !ok || k == "" {
+			return nil, fmt.Errorf("%q is not key=value", pair)
+		}
+		c[k] = strings.TrimSpace(v)
+	}
+	return c, nil
+}
@@ -0,0 +1,95 @@
+package gologops
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLoggerFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.log")
+	t.Setenv("LOGOPS_FORMAT", "json")
+	t.Setenv("LOGOPS_TIME_FORMAT", "kitchen")
+	t.Setenv("LOGOPS_LEVEL", "warn")
+	t.Setenv("LOGOPS_FLAGS", "shortfile, method")
+	t.Setenv("LOGOPS_OUTPUT", path)
+	t.Setenv("LOGOPS_CONTEXT", "svc=api, env = prod")
+
+	l := NewLogger()
+	l.Info("filtered")
+	l.Warn("written")
+	if other := NewLogger(); other.sinkList()[0].writer != l.sinkList()[0].writer {
+		t.Errorf("LOGOPS_OUTPUT opened again by another logger")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		t.Fatalf("%v in %q", err, b)
+	}
+	if obj["msg"] != "written" || obj["svc"] != "api" || obj["env"] != "prod" {
+		t.Errorf("unexpected line %q", b)
+	}
+	if !strings.HasPrefix(obj["file"].(string), "env_test.go.") || obj["func"] == nil {
+		t.Errorf("flags not set: %q", b)
+	}
+	if ts, _ := obj["time"].(string); !strings.HasSuffix(ts, "M") {
+		t.Errorf("time format not set: %q", b)
+	}
+}
+
+func TestConfigureFromEnvErrors(t *testing.T) {
+	t.Setenv("LOGOPS_FORMAT", "xml")
+	t.Setenv("LOGOPS_LEVEL", "verbose")
+	t.Setenv("LOGOPS_FLAGS", "shortfile,color")
+	t.Setenv("LOGOPS_OUTPUT", filepath.Join(t.TempDir(), "missing", "out.log"))
+	t.Setenv("LOGOPS_CONTEXT", `{"svc": "api"}`)
+
+	w := &testingLockedBuffer{}
+	l := NewLoggerWithFormatter(w, TextFormatter{TimeFormat: staticTime})
+	err := l.ConfigureFromEnv()
+	for _, name := range []string{"LOGOPS_FORMAT", "LOGOPS_LEVEL", "LOGOPS_FLAGS", "LOGOPS_OUTPUT"} {
+		if err == nil || !strings.Contains(err.Error(), name+":") {
+			t.Errorf("%s not reported in %v", name, err)
+		}
+	}
+	l.Debug("msg")
+	if got := w.String(); got != "static DEBUG\t [svc=api] msg\n" {
+		t.Errorf("valid variables should apply, invalid ones not: %q", got)
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	for _, in := range []string{"a", "=b", "a=b,c"} {
+		if _, err := parseContext(in); err == nil {
+			t.Errorf("%q parsed", in)
+		}
+	}
+	c, err := parseContext(`{"retries": 3, "tags": ["x"]}`)
+	if err != nil || c["retries"] != 3.0 {
+		t.Errorf("JSON context: %v, %v", c, err)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	for in, want := range map[string]int32{
+		"":                      0,
+		"file":                  Llongfile,
+		"LongFile, method":      Llongfile | Lmethod,
+		" shortfile,,Stack ":    Lshortfile | Lstack,
+		"shortfile,Method,file": Lshortfile | Lmethod | Llongfile,
+	} {
+		got, err := ParseFlags(in)
+		if err != nil || got != want {
+			t.Errorf("ParseFlags(%q): want %d, got %d, %v", in, want, got, err)
+		}
+	}
+	if _, err := ParseFlags("shortfile,Color"); err == nil || err.Error() != `unknown flag "Color"` {
+		t.Errorf("unknown flag: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package gologops
+
+import "sync"
+
+// FatalExitCode is the code passed to the exit function after a fatal log
+const FatalExitCode = 1
+
+var (
+	exitHooksMu sync.Mutex
+	exitHooks   []func()
+)
+
+// RegisterExitHook adds f to the functions run, in the order they were
+// registered, before a fatal log terminates the process
+func RegisterExitHook(f func()) {
+	exitHooksMu.Lock()
+	exitHooks = append(exitHooks, f)
+	exitHooksMu.Unlock()
+}
+
+func runExitHooks() {
+	exitHooksMu.Lock()
+	hooks := append([]func(){}, exitHooks...)
+	exitHooksMu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// SetExitFunc sets the function called by Fatal, Fatalf, FatalC and FatalE
+// once the line is written. It is os.Exit by default. A nil f makes fatal
+// logs return without exiting, as any other level does.
+func (l *Logger) SetExitFunc(f func(code int)) {
+	l.exitFunc.Store(f)
+}
+
+// exit flushes the writer, runs the exit hooks and calls the exit function,
+// if there is one
+func (l *Logger) exit() {
+	exitFunc := l.exitFunc.Load().(func(int))
+	if exitFunc == nil {
+		return
+	}
+	l.flush()
+	runExitHooks()
+	exitFunc(FatalExitCode)
+}
+
+type flusher interface {
+	Flush() error
+}
+
+type syncer interface {
+	Sync() error
+}
+
+// flush pushes pending data of the writers, when they know how
+func (l *Logger) flush() {
+	for _, s := range l.sinkList() {
+		s.flush()
+	}
+}
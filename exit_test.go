@@ -0,0 +1,95 @@
+package gologops
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type testingSyncWriter struct {
+	bytes.Buffer
+	synced bool
+}
+
+func (w *testingSyncWriter) Sync() error {
+	w.synced = true
+	return nil
+}
+
+func TestFatalExits(t *testing.T) {
+	var calls []string
+	RegisterExitHook(func() { calls = append(calls, "hook 1") })
+	RegisterExitHook(func() { calls = append(calls, "hook 2") })
+	defer func() { exitHooks = nil }()
+
+	fatalFuncs := map[string]func(l *Logger){
+		"Fatal":  func(l *Logger) { l.Fatal("bye") },
+		"Fatalf": func(l *Logger) { l.Fatalf("bye %d", 1) },
+		"FatalC": func(l *Logger) { l.FatalC(C{"a": "b"}, "bye") },
+		"FatalE": func(l *Logger) { l.FatalE(testingNotJSONableNError{}, nil, "bye") },
+	}
+	for name, fatal := range fatalFuncs {
+		calls = nil
+		var w testingSyncWriter
+		l := NewLoggerWithWriter(&w)
+		l.SetExitFunc(func(code int) {
+			if w.Len() == 0 {
+				t.Errorf("%s: exit before writing the line", name)
+			}
+			if !w.synced {
+				t.Errorf("%s: exit before syncing the writer", name)
+			}
+			calls = append(calls, "exit")
+			if code != FatalExitCode {
+				t.Errorf("%s: exit code: want %d, got %d", name, FatalExitCode, code)
+			}
+		})
+		fatal(l)
+		want := []string{"hook 1", "hook 2", "exit"}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("%s: calls: want %v, got %v", name, want, calls)
+		}
+	}
+}
+
+func TestFatalExitsWhenFiltered(t *testing.T) {
+	exited := false
+	l := NewLoggerWithWriter(&bytes.Buffer{})
+	l.SetLevel(NoneLevel)
+	l.SetExitFunc(func(int) { exited = true })
+	l.Fatal("not written")
+	if !exited {
+		t.Error("fatal log did not exit when its level is filtered")
+	}
+}
+
+func TestFatalNoExit(t *testing.T) {
+	hookRun := false
+	RegisterExitHook(func() { hookRun = true })
+	defer func() { exitHooks = nil }()
+
+	var buffer bytes.Buffer
+	l := NewLoggerWithWriter(&buffer)
+	l.SetExitFunc(nil)
+	l.Fatal("still alive")
+	if buffer.Len() == 0 {
+		t.Error("fatal line not written")
+	}
+	if hookRun {
+		t.Error("exit hook run without exiting")
+	}
+}
+
+func TestGlobalFatalExits(t *testing.T) {
+	var buffer bytes.Buffer
+	code := 0
+	defer SetWriter(defaultLogger.sinkList()[0].writer)
+	defer SetExitFunc(os.Exit)
+	SetWriter(&buffer)
+	SetExitFunc(func(c int) { code = c })
+	FatalE(testingNotJSONableNError{}, nil, "bye")
+	if code != FatalExitCode {
+		t.Errorf("exit code: want %d, got %d", FatalExitCode, code)
+	}
+}
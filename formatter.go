@@ -0,0 +1,161 @@
+package gologops
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Entry holds every part of a log line, ready to be written by a Formatter
+type Entry struct {
+	Time    time.Time // not written if zero
+	Level   Level
+	File    string // file and line number, only with Llongfile or Lshortfile
+	Func    string // function name, only with Lmethod
+	Err     error
+	Stack   Stack   // only with Lstack, for ErrorLevel and above
+	Fields  []Field // merged context, in the FieldOrder of the logger
+	Message string  // already formatted with its params
+}
+
+// Field is a key and its value taken from a context
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Frame is a function call in a Stack
+type Frame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// Stack is a stack trace, the innermost call first
+type Stack []Frame
+
+func (s Stack) String() string {
+	var buffer bytes.Buffer
+	for i, frame := range s {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		fmt.Fprintf(&buffer, "%s (%s:%d)", frame.Func, frame.File, frame.Line)
+	}
+	return buffer.String()
+}
+
+// Formatter writes an Entry into buffer as a single line, trailing newline included
+type Formatter interface {
+	Format(buffer *bytes.Buffer, e *Entry)
+}
+
+const jsonTimeFormat = time.RFC3339
+
+// JSONFormatter writes every line as a JSON object. It is the default format.
+type JSONFormatter struct {
+	TimeFormat string // time.RFC3339 if empty
+}
+
+func (f JSONFormatter) Format(buffer *bytes.Buffer, e *Entry) {
+	timeFormat := f.TimeFormat
+	if timeFormat == "" {
+		timeFormat = jsonTimeFormat
+	}
+	if e.Time.IsZero() {
+		buffer.WriteString(`{"lvl":`)
+	} else {
+		buffer.WriteString(`{"time":`)
+		writeJSONTime(buffer, e.Time, timeFormat)
+		buffer.WriteString(`, "lvl":`)
+	}
+	writeJSONString(buffer, e.Level.String())
+	if e.File != "" {
+		buffer.WriteString(`, "file":`)
+		writeJSONString(buffer, e.File)
+	}
+	if e.Func != "" {
+		buffer.WriteString(`, "func":`)
+		writeJSONString(buffer, e.Func)
+	}
+	if e.Err != nil {
+		writeJSONField(buffer, ErrFieldName)
+		buffer.WriteString(formatError(e.Err))
+	}
+	if e.Stack != nil {
+		writeJSONField(buffer, StackFieldName)
+		writeJSONValue(buffer, e.Stack)
+	}
+	for _, field := range e.Fields {
+		writeJSONField(buffer, field.Key)
+		writeJSONValue(buffer, field.Value)
+	}
+	buffer.WriteString(`, "msg":`)
+	writeJSONString(buffer, e.Message)
+	buffer.WriteString("}\n")
+}
+
+// writeJSONField writes the separator and key of a new object member
+func writeJSONField(buffer *bytes.Buffer, key string) {
+	buffer.WriteString(", ")
+	writeJSONString(buffer, key)
+	buffer.WriteByte(':')
+}
+
+const textTimeFormat = "15:04:05.000"
+
+// TextFormatter writes human friendly lines, the "dev" format
+type TextFormatter struct {
+	TimeFormat string // "15:04:05.000" if empty
+}
+
+func (f TextFormatter) Format(buffer *bytes.Buffer, e *Entry) {
+	timeFormat := f.TimeFormat
+	if timeFormat == "" {
+		timeFormat = textTimeFormat
+	}
+	if !e.Time.IsZero() {
+		buffer.Write(e.Time.AppendFormat(buffer.AvailableBuffer(), timeFormat))
+		buffer.WriteByte(' ')
+	}
+	buffer.WriteString(e.Level.String())
+	if e.File != "" {
+		buffer.WriteByte(' ')
+		buffer.WriteString(e.File)
+	}
+	if e.Func != "" {
+		buffer.WriteByte(' ')
+		buffer.WriteString(e.Func)
+	}
+	buffer.WriteByte('\t')
+	if e.Err != nil {
+		writeTextField(buffer, ErrFieldName)
+		buffer.WriteString(formatError(e.Err))
+		buffer.WriteByte(']')
+	}
+	if e.Stack != nil {
+		writeTextField(buffer, StackFieldName)
+		buffer.WriteString(e.Stack.String())
+		buffer.WriteByte(']')
+	}
+	for _, field := range e.Fields {
+		writeTextField(buffer, field.Key)
+		writeTextValue(buffer, field.Value)
+		buffer.WriteByte(']')
+	}
+	buffer.WriteByte(' ')
+	buffer.WriteString(e.Message)
+	buffer.WriteByte('\n')
+}
+
+// writeTextField writes the opening of a "[key=value]" field
+func writeTextField(buffer *bytes.Buffer, key string) {
+	buffer.WriteString(" [")
+	buffer.WriteString(key)
+	buffer.WriteByte('=')
+}
+
+// formatterValue wraps any Formatter so it can be kept in an atomic.Value
+type formatterValue struct {
+	Formatter
+}
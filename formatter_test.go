@@ -0,0 +1,75 @@
+package gologops
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTextFormatter(t *testing.T) {
+	var buffer bytes.Buffer
+	e := Entry{
+		Time:    time.Date(2016, 1, 2, 3, 4, 5, 6e6, time.UTC),
+		Level:   WarnLevel,
+		File:    "logger.go.12",
+		Func:    "main.main",
+		Fields:  []Field{{"a", "A"}},
+		Message: "España y olé",
+	}
+	TextFormatter{}.Format(&buffer, &e)
+	want := "03:04:05.006 WARN logger.go.12 main.main\t [a=A] España y olé\n"
+	if got := buffer.String(); got != want {
+		t.Errorf("text line: want %q, got %q", want, got)
+	}
+}
+
+func TestFormatterTimeFormat(t *testing.T) {
+	var buffer bytes.Buffer
+	e := Entry{Time: time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC), Level: InfoLevel}
+	TextFormatter{TimeFormat: time.Kitchen}.Format(&buffer, &e)
+	if !strings.HasPrefix(buffer.String(), "3:04AM INFO") {
+		t.Errorf("text time format not honored: %q", buffer.String())
+	}
+	buffer.Reset()
+	JSONFormatter{TimeFormat: time.Kitchen}.Format(&buffer, &e)
+	if !strings.HasPrefix(buffer.String(), `{"time":"3:04AM"`) {
+		t.Errorf("JSON time format not honored: %q", buffer.String())
+	}
+}
+
+func TestLoggersWithDifferentFormatters(t *testing.T) {
+	var jsonBuffer, textBuffer bytes.Buffer
+	jsonLogger := NewLoggerWithFormatter(&jsonBuffer, JSONFormatter{})
+	textLogger := NewLoggerWithFormatter(&textBuffer, TextFormatter{})
+	for _, msg := range stringsForTesting {
+		jsonBuffer.Reset()
+		textBuffer.Reset()
+		jsonLogger.InfoC(contextForTesting, msg)
+		textLogger.InfoC(contextForTesting, msg)
+
+		var obj map[string]string
+		if err := json.Unmarshal(jsonBuffer.Bytes(), &obj); err != nil {
+			t.Errorf("JSON logger: %v", err)
+		} else if obj["msg"] != msg {
+			t.Errorf("JSON logger msg: want %q, got %q", msg, obj["msg"])
+		}
+		if !strings.HasSuffix(textBuffer.String(), " "+msg+"\n") {
+			t.Errorf("text logger: %q does not end with message %q", textBuffer.String(), msg)
+		}
+		if json.Valid(textBuffer.Bytes()) {
+			t.Errorf("text logger wrote JSON: %q", textBuffer.String())
+		}
+	}
+}
+
+func TestSetFormatter(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetFormatter(TextFormatter{})
+	l.Info("not JSON anymore")
+	if json.Valid(buffer.Bytes()) {
+		t.Errorf("formatter not changed: %q", buffer.String())
+	}
+}
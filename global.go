@@ -1,8 +1,12 @@
 package gologops
 
-import "io"
+import (
+	"io"
+	"log"
+	"time"
+)
 
-// Global logger
+// Global logger, configured by the LOGOPS_* environment variables at start
 var defaultLogger = NewLogger()
 
 func DebugC(context C, message string, params ...interface{}) {
@@ -60,19 +64,43 @@ func Error(message string) {
 
 func FatalC(context C, message string, params ...interface{}) {
 	defaultLogger.LogC(logLine{level: CriticalLevel, localCx: context, message: message, params: params})
+	defaultLogger.exit()
 }
 
 func Fatalf(message string, params ...interface{}) {
 	defaultLogger.LogC(logLine{level: CriticalLevel, message: message, params: params})
+	defaultLogger.exit()
 }
 
 func Fatal(message string) {
 	defaultLogger.LogC(logLine{level: CriticalLevel, message: message})
+	defaultLogger.exit()
 }
 
 func FatalE(err error, context C, message string, params ...interface{}) {
 
 	defaultLogger.LogC(logLine{err: err, level: CriticalLevel, localCx: context, message: message, params: params})
+	defaultLogger.exit()
+}
+
+func PanicE(err error, context C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{err: err, level: CriticalLevel, localCx: context, message: message, params: params})
+	panic(sprintf(message, params))
+}
+
+func PanicC(context C, message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{level: CriticalLevel, localCx: context, message: message, params: params})
+	panic(sprintf(message, params))
+}
+
+func Panicf(message string, params ...interface{}) {
+	defaultLogger.LogC(logLine{level: CriticalLevel, message: message, params: params})
+	panic(sprintf(message, params))
+}
+
+func Panic(message string) {
+	defaultLogger.LogC(logLine{level: CriticalLevel, message: message})
+	panic(message)
 }
 
 func SetLevel(lvl Level) {
@@ -91,6 +119,50 @@ func SetWriter(w io.Writer) {
 	defaultLogger.SetWriter(w)
 }
 
+func AddSink(s Sink) {
+	defaultLogger.AddSink(s)
+}
+
+func SetSampling(s Sampling) {
+	defaultLogger.SetSampling(s)
+}
+
+func AddHook(h Hook) {
+	defaultLogger.AddHook(h)
+}
+
+func SetDedup(window time.Duration) {
+	defaultLogger.SetDedup(window)
+}
+
+func SetRateLimit(lvl Level, perSecond float64, burst int) {
+	defaultLogger.SetRateLimit(lvl, perSecond, burst)
+}
+
 func SetFlags(flags int32) {
 	defaultLogger.SetFlags(flags)
 }
+
+func SetFormatter(f Formatter) {
+	defaultLogger.SetFormatter(f)
+}
+
+func With(c C) *Logger {
+	return defaultLogger.With(c)
+}
+
+func SetFieldOrder(order FieldOrder) {
+	defaultLogger.SetFieldOrder(order)
+}
+
+func SetExitFunc(f func(code int)) {
+	defaultLogger.SetExitFunc(f)
+}
+
+func StdLogger(lvl Level) *log.Logger {
+	return defaultLogger.StdLogger(lvl)
+}
+
+func RedirectStdLog(lvl Level) (restore func()) {
+	return defaultLogger.RedirectStdLog(lvl)
+}
@@ -0,0 +1,37 @@
+package gologops
+
+// Hook is called with every line passing the level of a logger, after
+// sampling and deduplication and before the line is formatted. The Entry has
+// the level, message, merged context and error of the line, and the hook may
+// change any of them, but not keep it: entries are reused once the line is
+// written. A level out of AllLevel..NoneLevel is written as "Level(n)".
+// Returning false drops the line, and the hooks after it are not called
+type Hook interface {
+	Fire(e *Entry) bool
+}
+
+// HookFunc is a function used as a Hook
+type HookFunc func(e *Entry) bool
+
+func (f HookFunc) Fire(e *Entry) bool {
+	return f(e)
+}
+
+// AddHook adds h to l and every logger sharing its output. Hooks are called
+// in the order they were added, from the goroutine writing the line
+func (l *Logger) AddHook(h Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hooks, _ := l.hooks.Load().([]Hook)
+	l.hooks.Store(append(hooks[:len(hooks):len(hooks)], h))
+}
+
+func (l *Logger) fireHooks(e *Entry) bool {
+	hooks, _ := l.hooks.Load().([]Hook)
+	for _, h := range hooks {
+		if !h.Fire(e) {
+			return false
+		}
+	}
+	return true
+}
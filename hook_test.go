@@ -0,0 +1,70 @@
+package gologops
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, TextFormatter{TimeFormat: staticTime})
+	l.SetLevel(InfoLevel)
+	l.SetContext(C{"svc": "test"})
+
+	var calls []string
+	l.AddHook(HookFunc(func(e *Entry) bool {
+		calls = append(calls, "first "+e.Message)
+		return e.Message != "vetoed"
+	}))
+	l.With(C{"a": "b"}).AddHook(HookFunc(func(e *Entry) bool {
+		calls = append(calls, "second "+e.Message)
+		e.Message = strings.ToUpper(e.Message)
+		e.Fields = append(e.Fields, Field{"hooked", len(e.Fields)})
+		return true
+	}))
+
+	l.Debug("filtered")
+	l.Info("vetoed")
+	l.InfoC(C{"x": "y"}, "changed")
+
+	want := []string{"first vetoed", "first changed", "second changed"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("hook calls: want %q, got %q", want, calls)
+	}
+	if got, want := buffer.String(), "static INFO\t [x=y] [svc=test] [hooked=2] CHANGED\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestHookLevel(t *testing.T) {
+	var buffer, errBuffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.AddSink(Sink{Writer: &errBuffer, Level: ErrorLevel})
+	l.AddHook(HookFunc(func(e *Entry) bool {
+		if e.Err != nil {
+			e.Level = ErrorLevel
+		}
+		return true
+	}))
+	l.InfoC(nil, "not an error")
+	l.LogC(logLine{level: InfoLevel, message: "with error", err: testingNestedError{Text: "x"}})
+	if !strings.Contains(errBuffer.String(), "with error") || strings.Contains(errBuffer.String(), "not an error") {
+		t.Errorf("sinks should use the level set by hooks: %q", errBuffer.String())
+	}
+}
+
+func TestHookInvalidLevel(t *testing.T) {
+	for _, f := range []Formatter{JSONFormatter{TimeFormat: staticTime}, TextFormatter{TimeFormat: staticTime}} {
+		var buffer bytes.Buffer
+		l := NewLoggerWithFormatter(&buffer, f)
+		l.AddHook(HookFunc(func(e *Entry) bool {
+			e.Level = 9
+			return true
+		}))
+		l.Info("msg")
+		if !strings.Contains(buffer.String(), "Level(9)") {
+			t.Errorf("%T: unexpected line %q", f, buffer.String())
+		}
+	}
+}
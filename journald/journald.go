@@ -0,0 +1,181 @@
+// Package journald sends log lines to systemd-journald with its native
+// protocol, so the level becomes the journal PRIORITY. A Writer is both the
+// formatter and the writer of a Logger:
+//
+//	w, err := journald.Dial(journald.Options{})
+//	l := gologops.NewLoggerWithFormatter(w, w)
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TDAF/gologops"
+	"github.com/TDAF/gologops/syslog"
+)
+
+// DefaultSocket is where journald listens for native messages
+const DefaultSocket = "/run/systemd/journal/socket"
+
+// Options of a Writer. Empty fields get a default
+type Options struct {
+	Socket     string // DefaultSocket
+	Identifier string // SYSLOG_IDENTIFIER, base name of os.Args[0]
+}
+
+// Writer formats lines as journal entries and sends each one in a datagram.
+// MESSAGE and PRIORITY come from the line, CODE_FILE, CODE_LINE and
+// CODE_FUNC from the flags of the logger, and every context field becomes
+// a journal field with its key in upper case. Entries too big for a
+// datagram are not supported. When a write fails the Writer dials again and
+// retries once
+type Writer struct {
+	opts Options
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Dial connects to the journal socket
+func Dial(opts Options) (*Writer, error) {
+	if opts.Socket == "" {
+		opts.Socket = DefaultSocket
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+	w := &Writer{opts: opts}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) connect() error {
+	conn, err := net.Dial("unixgram", w.opts.Socket)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+// Format writes e as the fields of a journal entry
+func (w *Writer) Format(buffer *bytes.Buffer, e *gologops.Entry) {
+	writeField(buffer, "MESSAGE", e.Message)
+	writeField(buffer, "PRIORITY", strconv.Itoa(syslog.Severity(e.Level)))
+	writeField(buffer, "SYSLOG_IDENTIFIER", w.opts.Identifier)
+	if e.File != "" {
+		// file and line come together as "file.line"
+		if i := strings.LastIndexByte(e.File, '.'); i >= 0 {
+			writeField(buffer, "CODE_FILE", e.File[:i])
+			writeField(buffer, "CODE_LINE", e.File[i+1:])
+		}
+	}
+	if e.Func != "" {
+		writeField(buffer, "CODE_FUNC", e.Func)
+	}
+	if e.Err != nil {
+		writeField(buffer, FieldName(gologops.ErrFieldName), gologops.TextValue(e.Err))
+	}
+	if e.Stack != nil {
+		writeField(buffer, FieldName(gologops.StackFieldName), e.Stack.String())
+	}
+	for _, field := range e.Fields {
+		writeField(buffer, FieldName(field.Key), gologops.TextValue(field.Value))
+	}
+}
+
+// reservedFields are the fields Format sets itself. Context keys mapping to
+// them get a FIELD_ prefix instead, so they do not change the priority or the
+// message of the entry
+var reservedFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// FieldName returns the journal field for a context key: upper case letters,
+// digits and underscores, not starting with an underscore or a digit, at
+// most 64 characters. Keys of fields set by Format, such as "priority", are
+// prefixed with FIELD_
+func FieldName(key string) string {
+	name := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(name) < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			name = append(name, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9' && len(name) > 0:
+			name = append(name, c)
+		case len(name) > 0:
+			name = append(name, '_')
+		}
+	}
+	if len(name) == 0 {
+		return "FIELD"
+	}
+	if reservedFields[string(name)] {
+		return "FIELD_" + string(name)
+	}
+	return string(name)
+}
+
+// writeField writes a field as "NAME=value\n", or with the binary form of the
+// protocol if value has newlines
+func writeField(buffer *bytes.Buffer, name, value string) {
+	buffer.WriteString(name)
+	if strings.IndexByte(value, '\n') < 0 {
+		buffer.WriteByte('=')
+		buffer.WriteString(value)
+		buffer.WriteByte('\n')
+		return
+	}
+	buffer.WriteByte('\n')
+	binary.Write(buffer, binary.LittleEndian, uint64(len(value)))
+	buffer.WriteString(value)
+	buffer.WriteByte('\n')
+}
+
+// Write sends p, an entry produced by Format, reconnecting if needed
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		if _, err := w.conn.Write(p); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return 0, err
+	}
+	if _, err := w.conn.Write(p); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,194 @@
+//go:build unix
+
+package journald
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TDAF/gologops"
+)
+
+// fakeJournal listens on a local socket as journald does
+type fakeJournal struct {
+	conn *net.UnixConn
+	path string
+}
+
+func newFakeJournal(t *testing.T) *fakeJournal {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &fakeJournal{conn: conn, path: path}
+}
+
+// entry reads a datagram and decodes its fields
+func (j *fakeJournal) entry(t *testing.T) map[string]string {
+	t.Helper()
+	j.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 65536)
+	n, err := j.conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]string)
+	r := bufio.NewReader(bytes.NewReader(b[:n]))
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return fields
+		}
+		if err != nil {
+			t.Fatalf("%v in %q", err, b[:n])
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if name, value, ok := strings.Cut(line, "="); ok {
+			fields[name] = value
+			continue
+		}
+		var size uint64
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			t.Fatal(err)
+		}
+		value := make([]byte, size+1)
+		if _, err := io.ReadFull(r, value); err != nil || value[size] != '\n' {
+			t.Fatalf("bad binary field %s: %v", line, err)
+		}
+		fields[line] = string(value[:size])
+	}
+}
+
+func TestJournal(t *testing.T) {
+	j := newFakeJournal(t)
+	w, err := Dial(Options{Socket: j.path, Identifier: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := gologops.NewLoggerWithFormatter(w, w)
+	l.SetFlags(gologops.Lshortfile | gologops.Lmethod)
+	l.SetContext(gologops.C{"corr": "123", "retries": 2, "multi": "line 1\nline 2"})
+
+	l.ErrorE(testingError{}, nil, "something failed")
+	want := map[string]string{
+		"MESSAGE":           "something failed",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "app",
+		"CODE_FILE":         "journald_test.go",
+		"CODE_FUNC":         "github.com/TDAF/gologops/journald.TestJournal",
+		"ERR":               "testing error",
+		"CORR":              "123",
+		"RETRIES":           "2",
+		"MULTI":             "line 1\nline 2",
+	}
+	got := j.entry(t)
+	if got["CODE_LINE"] == "" {
+		t.Error("missing CODE_LINE")
+	}
+	delete(got, "CODE_LINE")
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("entry:\nwant %v\ngot  %v", want, got)
+	}
+
+	l.Debug("debug")
+	if got := j.entry(t); got["PRIORITY"] != "7" || got["MESSAGE"] != "debug" {
+		t.Errorf("unexpected entry %v", got)
+	}
+}
+
+func TestReservedFields(t *testing.T) {
+	j := newFakeJournal(t)
+	w, err := Dial(Options{Socket: j.path, Identifier: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := gologops.NewLoggerWithFormatter(w, w)
+
+	l.ErrorC(gologops.C{"priority": "high", "message": "other", "syslog_identifier": "x"}, "msg")
+	got := j.entry(t)
+	if got["PRIORITY"] != "3" || got["MESSAGE"] != "msg" || got["SYSLOG_IDENTIFIER"] != "app" {
+		t.Errorf("context changed fields set by the writer: %v", got)
+	}
+	if got["FIELD_PRIORITY"] != "high" || got["FIELD_MESSAGE"] != "other" || got["FIELD_SYSLOG_IDENTIFIER"] != "x" {
+		t.Errorf("context fields lost: %v", got)
+	}
+}
+
+type testingError struct{}
+
+func (testingError) Error() string { return "testing error" }
+
+type testingPtrError struct{ msg string }
+
+func (e *testingPtrError) Error() string { return e.msg }
+
+func TestNilError(t *testing.T) {
+	var buffer bytes.Buffer
+	w := &Writer{opts: Options{Identifier: "app"}}
+	w.Format(&buffer, &gologops.Entry{Level: gologops.ErrorLevel, Message: "msg", Err: (*testingPtrError)(nil)})
+	if !strings.Contains(buffer.String(), "\nERR=<nil>\n") {
+		t.Errorf("nil pointer error not written as <nil>: %q", buffer.String())
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	names := map[string]string{
+		"corr":       "CORR",
+		"Span-ID":    "SPAN_ID",
+		"_private":   "PRIVATE",
+		"2fa":        "FA",
+		"España":     "ESPA__A",
+		"":           "FIELD",
+		"a.b c":      "A_B_C",
+		"MESSAGE_ID": "MESSAGE_ID",
+		"priority":   "FIELD_PRIORITY",
+		"Code-Line":  "FIELD_CODE_LINE",
+	}
+	for key, want := range names {
+		if got := FieldName(key); got != want {
+			t.Errorf("field name for %q: want %q, got %q", key, want, got)
+		}
+	}
+	if got := FieldName(strings.Repeat("x", 100)); len(got) != 64 {
+		t.Errorf("field name longer than 64: %d", len(got))
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	j := newFakeJournal(t)
+	w, err := Dial(Options{Socket: j.path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := gologops.NewLoggerWithFormatter(w, w)
+
+	// journald restarts, with a new socket in the same place
+	j.conn.Close()
+	if err := os.Remove(j.path); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: j.path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	j.conn = conn
+	l.Info("after restart")
+	if got := j.entry(t); got["MESSAGE"] != "after restart" {
+		t.Errorf("unexpected entry %v", got)
+	}
+}
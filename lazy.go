@@ -0,0 +1,75 @@
+package gologops
+
+import "sync/atomic"
+
+// Enabled tells whether lines of lvl pass the level of l and reach at least
+// one of its sinks. Use it to skip building expensive arguments:
+//
+//	if l.Enabled(gologops.DebugLevel) {
+//		l.Debugf("state: %s", dump(state))
+//	}
+func (l *Logger) Enabled(lvl Level) bool {
+	return Level(atomic.LoadInt32(&l.level)) <= lvl && anySinkAccepts(l.sinkList(), lvl)
+}
+
+// DebugFn writes the context and message returned by f, that is only called
+// when debug lines are enabled. The message is written as is
+func (l *Logger) DebugFn(f func() (C, string)) {
+	if l.Enabled(DebugLevel) {
+		cx, message := f()
+		l.LogC(logLine{level: DebugLevel, localCx: cx, message: message})
+	}
+}
+
+func (l *Logger) InfoFn(f func() (C, string)) {
+	if l.Enabled(InfoLevel) {
+		cx, message := f()
+		l.LogC(logLine{level: InfoLevel, localCx: cx, message: message})
+	}
+}
+
+func (l *Logger) WarnFn(f func() (C, string)) {
+	if l.Enabled(WarnLevel) {
+		cx, message := f()
+		l.LogC(logLine{level: WarnLevel, localCx: cx, message: message})
+	}
+}
+
+func (l *Logger) ErrorFn(f func() (C, string)) {
+	if l.Enabled(ErrorLevel) {
+		cx, message := f()
+		l.LogC(logLine{level: ErrorLevel, localCx: cx, message: message})
+	}
+}
+
+func Enabled(lvl Level) bool {
+	return defaultLogger.Enabled(lvl)
+}
+
+func DebugFn(f func() (C, string)) {
+	if defaultLogger.Enabled(DebugLevel) {
+		cx, message := f()
+		defaultLogger.LogC(logLine{level: DebugLevel, localCx: cx, message: message})
+	}
+}
+
+func InfoFn(f func() (C, string)) {
+	if defaultLogger.Enabled(InfoLevel) {
+		cx, message := f()
+		defaultLogger.LogC(logLine{level: InfoLevel, localCx: cx, message: message})
+	}
+}
+
+func WarnFn(f func() (C, string)) {
+	if defaultLogger.Enabled(WarnLevel) {
+		cx, message := f()
+		defaultLogger.LogC(logLine{level: WarnLevel, localCx: cx, message: message})
+	}
+}
+
+func ErrorFn(f func() (C, string)) {
+	if defaultLogger.Enabled(ErrorLevel) {
+		cx, message := f()
+		defaultLogger.LogC(logLine{level: ErrorLevel, localCx: cx, message: message})
+	}
+}
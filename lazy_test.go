@@ -0,0 +1,44 @@
+package gologops
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	l := NewLoggerWithFormatter(&bytes.Buffer{}, JSONFormatter{})
+	l.SetLevel(InfoLevel)
+	if l.Enabled(DebugLevel) || !l.Enabled(InfoLevel) || !l.Enabled(CriticalLevel) {
+		t.Errorf("Enabled does not follow the level of the logger")
+	}
+
+	l = NewLoggerWithFormatter(&bytes.Buffer{}, JSONFormatter{})
+	l.AddSink(Sink{Writer: &bytes.Buffer{}, Level: WarnLevel})
+	l.SetSinkLevel(ErrorLevel)
+	if l.Enabled(InfoLevel) || !l.Enabled(WarnLevel) {
+		t.Errorf("Enabled does not follow the level of the sinks")
+	}
+}
+
+func TestLazy(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, TextFormatter{TimeFormat: staticTime})
+	l.SetLevel(InfoLevel)
+	l.SetFlags(Lshortfile)
+
+	called := false
+	l.DebugFn(func() (C, string) {
+		called = true
+		return nil, "expensive"
+	})
+	if called || buffer.Len() > 0 {
+		t.Errorf("func of a filtered line called")
+	}
+
+	l.InfoFn(func() (C, string) { return C{"size": 3}, "100% built" })
+	if got := buffer.String(); !strings.HasPrefix(got, "static INFO lazy_test.go.") ||
+		!strings.HasSuffix(got, "\t [size=3] 100% built\n") {
+		t.Errorf("unexpected line %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+package gologops
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]Level{
+		"all": AllLevel, "DEBUG": DebugLevel, " info ": InfoLevel, "Warn": WarnLevel, "warning": WarnLevel,
+		"error": ErrorLevel, "FATAL": CriticalLevel, "critical": CriticalLevel, "none": NoneLevel, "off": NoneLevel,
+	} {
+		got, err := ParseLevel(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q): want %v, got %v, %v", in, want, got, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("unknown level parsed")
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	for lvl := AllLevel; lvl <= NoneLevel; lvl++ {
+		text, err := lvl.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back Level
+		if err := back.UnmarshalText(text); err != nil || back != lvl {
+			t.Errorf("%v did not survive MarshalText: %s, %v", lvl, text, err)
+		}
+	}
+	if got := Level(42).String(); got != "Level(42)" {
+		t.Errorf("String of an invalid level: %q", got)
+	}
+	if _, err := Level(-1).MarshalText(); err == nil {
+		t.Errorf("invalid level marshaled")
+	}
+
+	var config struct{ Level Level }
+	if err := json.Unmarshal([]byte(`{"Level": "critical"}`), &config); err != nil || config.Level != CriticalLevel {
+		t.Errorf("level from JSON: %v, %v", config.Level, err)
+	}
+	if b, _ := json.Marshal(config); string(b) != `{"Level":"FATAL"}` {
+		t.Errorf("level to JSON: %s", b)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	lvl := InfoLevel
+	fs.TextVar(&lvl, "level", InfoLevel, "")
+	if err := fs.Parse([]string{"-level", "warn"}); err != nil || lvl != WarnLevel {
+		t.Errorf("level from flag: %v, %v", lvl, err)
+	}
+}
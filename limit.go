@@ -0,0 +1,173 @@
+package gologops
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RepeatedFieldName is the field with the count of duplicates in the line
+// written at the end of a dedup window
+const RepeatedFieldName = "repeated"
+
+type dedupKey struct {
+	level   Level
+	message string
+	err     string
+}
+
+type dedupLine struct {
+	start    time.Time
+	repeated int
+	first    logLine
+}
+
+type deduper struct {
+	l      *Logger // writes the repeated lines
+	window time.Duration
+	mu     sync.Mutex
+	lines  map[dedupKey]*dedupLine
+	swept  time.Time
+}
+
+// SetDedup collapses repeated lines of l and every logger sharing its output.
+// A line with the same level, message, taken before its params are applied,
+// and error text as one written less than window ago is dropped; once the
+// window ends, a line "repeated N times", with the context of the first one,
+// tells how many were. A zero window turns deduplication off
+func (l *Logger) SetDedup(window time.Duration) {
+	var d *deduper
+	if window > 0 {
+		d = &deduper{l: l, window: window, lines: map[dedupKey]*dedupLine{}}
+	}
+	l.deduper.Store(d)
+}
+
+// SetRateLimit limits the lines of lvl written by l and every logger sharing
+// its output to perSecond, with bursts of up to burst lines, at least one. A
+// perSecond of zero or less removes the limit of lvl
+func (l *Logger) SetRateLimit(lvl Level, perSecond float64, burst int) {
+	burst = max(burst, 1)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	buckets, _ := l.buckets.Load().(map[Level]*bucket)
+	updated := make(map[Level]*bucket, len(buckets)+1)
+	for k, v := range buckets {
+		updated[k] = v
+	}
+	if perSecond > 0 {
+		updated[lvl] = &bucket{rate: perSecond, burst: float64(burst), tokens: float64(burst)}
+	} else {
+		delete(updated, lvl)
+	}
+	l.buckets.Store(updated)
+}
+
+// Suppressed returns the number of lines dropped by deduplication and rate
+// limits since l was created
+func (l *Logger) Suppressed() uint64 {
+	return l.suppressed.Load()
+}
+
+// limited tells whether ll is dropped as a duplicate or by the rate limit of
+// its level
+func (l *Logger) limited(ll logLine) bool {
+	if ll.summary {
+		return false
+	}
+	d, _ := l.deduper.Load().(*deduper)
+	if d != nil && !d.keep(ll) {
+		l.suppressed.Add(1)
+		return true
+	}
+	buckets, _ := l.buckets.Load().(map[Level]*bucket)
+	if b := buckets[ll.level]; b != nil && !b.take() {
+		l.suppressed.Add(1)
+		return true
+	}
+	return false
+}
+
+func (d *deduper) keep(ll logLine) bool {
+	key := dedupKey{level: ll.level, message: ll.message}
+	if ll.err != nil {
+		key.err = TextValue(ll.err) // "<nil>" for nil pointers
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if line := d.lines[key]; line != nil && now.Sub(line.start) < d.window {
+		if line.repeated == 0 {
+			time.AfterFunc(line.start.Add(d.window).Sub(now), func() { d.report(key, line) })
+		}
+		line.repeated++
+		return false
+	}
+	if ll.pc == 0 && atomic.LoadInt32(&d.l.flags)&(Llongfile|Lshortfile|Lmethod) != 0 {
+		// the repeated line is written later, with the caller of this one:
+		// 5 = External Function + InfoC | Warn | Error... + logC + limited + keep
+		var pcs [1]uintptr
+		runtime.Callers(callerDeepLevel-1, pcs[:])
+		ll.pc = pcs[0]
+	}
+	d.lines[key] = &dedupLine{start: now, first: ll}
+	if now.Sub(d.swept) >= d.window {
+		for k, line := range d.lines {
+			if line.repeated == 0 && now.Sub(line.start) >= d.window {
+				delete(d.lines, k)
+			}
+		}
+		d.swept = now
+	}
+	return true
+}
+
+func (d *deduper) report(key dedupKey, line *dedupLine) {
+	d.mu.Lock()
+	repeated := line.repeated
+	if d.lines[key] == line {
+		delete(d.lines, key)
+	}
+	d.mu.Unlock()
+	first := line.first
+	localCx := make(C, len(first.localCx)+1)
+	for k, v := range first.localCx {
+		localCx[k] = v
+	}
+	localCx[RepeatedFieldName] = repeated
+	d.l.LogC(logLine{
+		level:     first.level,
+		localCx:   localCx,
+		requestCx: first.requestCx,
+		message:   "repeated %d times: %s",
+		params:    []interface{}{repeated, sprintf(first.message, first.params)},
+		err:       first.err,
+		pc:        first.pc,
+		summary:   true,
+	})
+}
+
+// bucket is a token bucket, refilled at rate tokens per second up to burst
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
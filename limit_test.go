@@ -0,0 +1,96 @@
+package gologops
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	w := &testingLockedBuffer{}
+	l := NewLoggerWithFormatter(w, TextFormatter{TimeFormat: staticTime})
+	l.SetDedup(30 * time.Millisecond)
+	down := errors.New("backend down")
+	for i := 0; i < 5; i++ {
+		l.ErrorE(down, C{"backend": "db"}, "call %d failed", i)
+		l.ErrorE(errors.New("other"), nil, "call %d failed", i)
+	}
+	if got := l.Suppressed(); got != 8 {
+		t.Errorf("suppressed: want 8, got %d", got)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for strings.Count(w.String(), "repeated 4 times") < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("no repeated lines: %q", w.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !strings.Contains(w.String(), "[backend=db] [repeated=4] repeated 4 times: call 0 failed\n") {
+		t.Errorf("unexpected repeated line: %q", w.String())
+	}
+
+	l.ErrorE(down, C{"backend": "db"}, "call %d failed", 5)
+	if got := strings.Count(w.String(), "\n"); got != 5 {
+		t.Errorf("line after the window should be written: %q", w.String())
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, TextFormatter{TimeFormat: staticTime})
+	l.SetRateLimit(ErrorLevel, 0.001, 3)
+	for i := 0; i < 10; i++ {
+		l.Errorf("error %d", i)
+		l.Infof("info %d", i)
+	}
+	if got := strings.Count(buffer.String(), "ERROR"); got != 3 {
+		t.Errorf("want a burst of 3 errors, got %q", buffer.String())
+	}
+	if got := strings.Count(buffer.String(), "INFO"); got != 10 {
+		t.Errorf("other levels should not be limited, got %q", buffer.String())
+	}
+
+	l.SetRateLimit(ErrorLevel, 0, 0)
+	buffer.Reset()
+	l.Error("error")
+	if buffer.Len() == 0 {
+		t.Errorf("limit not removed")
+	}
+}
+
+func TestDedupCaller(t *testing.T) {
+	w := &testingLockedBuffer{}
+	l := NewLoggerWithFormatter(w, TextFormatter{TimeFormat: staticTime})
+	l.SetFlags(Lshortfile | Lmethod)
+	l.SetDedup(20 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		l.Error("down")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(w.String(), "repeated 2 times") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no repeated line: %q", w.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	first, repeated := lines[0], lines[1]
+	caller := first[:strings.Index(first, "\t")]
+	if !strings.Contains(caller, "limit_test.go.") || !strings.HasPrefix(repeated, caller+"\t") {
+		t.Errorf("repeated line should have the caller of the first one:\n%s\n%s", first, repeated)
+	}
+}
+
+func TestDedupNilError(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, TextFormatter{TimeFormat: staticTime})
+	l.SetDedup(time.Minute)
+	for i := 0; i < 3; i++ {
+		l.ErrorE((*testingPtrErr)(nil), nil, "call failed")
+	}
+	if got := l.Suppressed(); got != 2 {
+		t.Errorf("suppressed: want 2, got %d", got)
+	}
+}
@@ -1,44 +1,90 @@
 package gologops
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
-// 6 = External Function + InfoC | Warn | Error... + logC + format + flagsInfo + stackInfo
+// 6 = External Function + InfoC | Warn | Error... + logC + entry + flagsInfo + stackInfo
 const callerDeepLevel int = 6
 
+// maxStackDepth is the maximum number of frames in the stack field
+const maxStackDepth = 32
+
 type Logger struct {
+	*core
+	parent      *Logger // nil but for loggers created by With
 	contextFunc atomic.Value
 	context     atomic.Value
-	level       int32
-	flags       int32
-	writer      io.Writer
-	mu          sync.Mutex
 }
 
+// core is the state shared by a logger and every child created with With
+type core struct {
+	exitFunc atomic.Value
+	level    int32
+	flags    int32
+	order    int32
+	sinks    atomic.Value // []*sink, replaced as a whole under mu
+	mu       sync.Mutex
+
+	sampler    atomic.Value // *sampler, nil without sampling
+	sampledOut atomic.Uint64
+	deduper    atomic.Value // *deduper, nil without deduplication
+	buckets    atomic.Value // map[Level]*bucket, replaced as a whole under mu
+	suppressed atomic.Uint64
+	hooks      atomic.Value // []Hook, replaced as a whole under mu
+}
+
+// NewLogger returns a logger writing to stdout, configured by the LOGOPS_*
+// environment variables described in ConfigureFromEnv. Invalid values are
+// reported with a warning line
 func NewLogger() *Logger {
-	return NewLoggerWithWriter(io.Writer(os.Stdout))
+	l := NewLoggerWithWriter(io.Writer(os.Stdout))
+	if err := l.ConfigureFromEnv(); err != nil {
+		l.Warnf("invalid logging configuration in environment: %v", err)
+	}
+	return l
 }
 
 func NewLoggerWithWriter(w io.Writer) *Logger {
-	l := &Logger{}
+	return NewLoggerWithFormatter(w, defaultFormatter)
+}
+
+// NewLoggerWithFormatter returns a logger writing to w the lines produced by f,
+// regardless of the LOGOPS_FORMAT environment variable
+func NewLoggerWithFormatter(w io.Writer, f Formatter) *Logger {
+	l := &Logger{core: &core{}}
+	l.sinks.Store([]*sink{newSink(Sink{Writer: w, Formatter: f}, 0)})
 	l.SetContextFunc(nil)
 	l.SetContext(nil)
-	l.SetLevel(allLevel)
+	l.SetExitFunc(os.Exit)
+	l.SetLevel(AllLevel)
 	l.SetFlags(Ldefaults)
-	l.writer = w
 	return l
 }
 
+// With returns a child logger adding c to the context of l. The child shares
+// level, flags, formatter, writer and sinks with l, so setting any of them on either
+// logger changes both. SetContext and SetContextFunc only affect the logger
+// they are called on. Context of the child takes precedence over the one of
+// its parent; a child without context function uses the one of its parent.
+func (l *Logger) With(c C) *Logger {
+	child := &Logger{core: l.core, parent: l}
+	child.SetContextFunc(nil)
+	child.SetContext(c)
+	return child
+}
+
 func (l *Logger) SetLevel(lvl Level) {
 	atomic.StoreInt32(&l.level, int32(lvl))
 }
@@ -52,10 +98,28 @@ func (l *Logger) SetContextFunc(f func() C) {
 	l.contextFunc.Store(f)
 }
 
+// SetWriter sets the writer of the first sink of l, the one given when l
+// was created
 func (l *Logger) SetWriter(w io.Writer) {
-	l.mu.Lock()
-	l.writer = w
-	l.mu.Unlock()
+	s := l.sinkList()[0]
+	s.mu.Lock()
+	s.writer = w
+	s.mu.Unlock()
+}
+
+// SetFormatter sets the formatter of the first sink of l
+func (l *Logger) SetFormatter(f Formatter) {
+	l.sinkList()[0].formatter.Store(formatterValue{f})
+}
+
+// SetSinkLevel sets the level of the first sink of l, AllLevel when l was
+// created. Lines still need to pass the level of l, set with SetLevel
+func (l *Logger) SetSinkLevel(lvl Level) {
+	atomic.StoreInt32(&l.sinkList()[0].level, int32(lvl))
+}
+
+func (l *Logger) SetFieldOrder(order FieldOrder) {
+	atomic.StoreInt32(&l.order, int32(order))
 }
 
 func (l *Logger) SetFlags(flags int32) {
@@ -66,58 +130,101 @@ func (l *Logger) AddFlags(flags int32) {
 	atomic.StoreInt32(&l.flags, atomic.LoadInt32(&l.flags)|flags)
 }
 
-func (l *Logger) format(buffer *bytes.Buffer, lline logLine) {
-	var dynamicContext C
-	now := time.Now()
+// entry returns the Entry of lline, taken from entryPool
+func (l *Logger) entry(lline logLine) *Entry {
+	e := entryPool.Get().(*Entry)
+	e.Time, e.Level, e.Err = lline.time, lline.level, lline.err
+	if !lline.ownTime {
+		e.Time = time.Now()
+	}
 
-	var flagsFields string
-	if f := atomic.LoadInt32(&l.flags); f&(Llongfile|Lshortfile|Lmethod) != 0 {
-		flagsFields = flagsInfo(f)
+	f := atomic.LoadInt32(&l.flags)
+	// summaries are written from a timer, with no caller of their own
+	if f&(Llongfile|Lshortfile|Lmethod) != 0 && !(lline.summary && lline.pc == 0) {
+		e.File, e.Func = flagsInfo(f, lline.pc)
+	}
+	if lline.stack != nil {
+		e.Stack = lline.stack
+	} else if f&Lstack != 0 && lline.level >= ErrorLevel {
+		e.Stack = stackTrace()
 	}
 
-	fmt.Fprintf(buffer, prefixFormat, now.Format(timeFormat), levelNames[lline.level], flagsFields)
+	l.fields(lline, e)
+	e.Message = sprintf(lline.message, lline.params)
+	return e
+}
+
+var entryPool = sync.Pool{New: func() interface{} { return &Entry{} }}
+
+// maxPooledFields keeps entries of unusually wide lines out of entryPool
+const maxPooledFields = 64
 
-	if lline.err != nil {
-		errMsg := formatError(lline.err)
-		fmt.Fprintf(buffer, errorFormat, ErrFieldName, errMsg)
+func putEntry(e *Entry) {
+	if cap(e.Fields) > maxPooledFields {
+		return
 	}
+	clear(e.Fields)
+	*e = Entry{Fields: e.Fields[:0]}
+	entryPool.Put(e)
+}
+
+func sprintf(message string, params []interface{}) string {
+	if len(params) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, params...)
+}
+
+// fields merges into e local, request (from a context.Context), dynamic and
+// logger context, the context of parent loggers the last. A key already
+// present in a previous context is skipped, as are ErrFieldName and
+// StackFieldName when e has an error or a stack. Fields come in the
+// FieldOrder of the logger
+func (l *Logger) fields(lline logLine, e *Entry) {
+	var dynamicContext C
 
-	for k, v := range lline.localCx {
-		if lline.err != nil && k == ErrFieldName {
-			continue
+	for lg := l; lg != nil; lg = lg.parent {
+		if contextFunc := lg.contextFunc.Load().(func() C); contextFunc != nil {
+			dynamicContext = contextFunc()
+			break
 		}
-		fmt.Fprintf(buffer, fieldFormat, k, v)
 	}
-	contextFunc := l.contextFunc.Load().(func() C)
-	if contextFunc != nil {
-		dynamicContext = contextFunc()
-		for k, v := range dynamicContext {
-			if lline.err != nil && k == ErrFieldName {
+	var layersArray [8]C // enough for most With chains, without allocating
+	layers := append(layersArray[:0], lline.localCx, lline.requestCx, dynamicContext)
+	for lg := l; lg != nil; lg = lg.parent {
+		layers = append(layers, lg.context.Load().(C))
+	}
+
+	fields := e.Fields[:0]
+	for i, layer := range layers {
+		start := len(fields)
+		for k, v := range layer {
+			if (e.Err != nil && k == ErrFieldName) || (e.Stack != nil && k == StackFieldName) {
 				continue
 			}
-			if _, already := lline.localCx[k]; !already {
-				fmt.Fprintf(buffer, fieldFormat, k, v)
+			if !inAnyContext(layers[:i], k) {
+				fields = append(fields, Field{k, v})
 			}
 		}
+		slices.SortFunc(fields[start:], compareFields)
 	}
-	loggerContext := l.context.Load().(C)
-	for k, v := range loggerContext {
-		if lline.err != nil && k == ErrFieldName {
-			continue
-		}
-		if _, already := lline.localCx[k]; !already {
-			if _, already := dynamicContext[k]; !already {
-				fmt.Fprintf(buffer, fieldFormat, k, v)
-			}
-		}
+	if FieldOrder(atomic.LoadInt32(&l.order)) == SortedOrder {
+		slices.SortFunc(fields, compareFields)
 	}
-	if len(lline.params) == 0 {
-		fmt.Fprintf(buffer, postfixFormat, lline.message)
-	} else {
-		m := fmt.Sprintf(lline.message, lline.params...)
-		fmt.Fprintf(buffer, postfixFormat, m)
+	e.Fields = fields
+}
+
+func compareFields(a, b Field) int {
+	return strings.Compare(a.Key, b.Key)
+}
+
+func inAnyContext(contexts []C, key string) bool {
+	for _, c := range contexts {
+		if _, already := c[key]; already {
+			return true
+		}
 	}
-	fmt.Fprintln(buffer) // newline at the end
+	return false
 }
 
 func formatError(err error) string {
@@ -131,29 +238,60 @@ func formatError(err error) string {
 		b.WriteByte('(')
 		b.WriteString(errJSON.Error())
 		b.WriteByte(')')
-		return fmt.Sprintf("%q", b.String()) // the string as a valid JSON object
+		msg := b.String()
+		b.Reset()
+		writeJSONString(b, msg) // the string as a valid JSON object
+	} else {
+		b.Truncate(b.Len() - 1) // remove trailing newline
 	}
-	b.Truncate(b.Len() - 1) // remove trailing newline
 	return b.String()
 }
 
+// LogC writes ll to every sink accepting its level, once the hooks of l let
+// it through. The error of a logger with a single sink is the one of its
+// writer
 func (l *Logger) LogC(ll logLine) error {
 	if Level(atomic.LoadInt32(&l.level)) <= ll.level {
+		sinks := l.sinkList()
+		if !anySinkAccepts(sinks, ll.level) || l.sampled(ll) || l.limited(ll) {
+			return nil
+		}
 
-		b := getBuffer()
-
-		l.format(b, ll)
-		l.mu.Lock()
-		_, err := l.writer.Write(b.Bytes())
-		l.mu.Unlock()
-
-		putBuffer(b)
+		e := l.entry(ll)
+		defer putEntry(e)
+		if !l.fireHooks(e) {
+			return nil
+		}
 
-		return err
+		if len(sinks) == 1 {
+			if !sinks[0].accepts(e.Level) {
+				return nil
+			}
+			return sinks[0].write(e)
+		}
+		var errs []error
+		for _, s := range sinks {
+			if !s.accepts(e.Level) {
+				continue
+			}
+			if err := s.write(e); err != nil {
+				errs = append(errs, &SinkError{Sink: s.name, Err: err})
+			}
+		}
+		return errors.Join(errs...)
 	}
 	return nil
 }
 
+func anySinkAccepts(sinks []*sink, lvl Level) bool {
+	for _, s := range sinks {
+		if s.accepts(lvl) {
+			return true
+		}
+	}
+	return false
+}
+
 //DebugC prints the logger. Arguments are handled in the manner of fmt.Printf.
 
 func (l *Logger) DebugC(context C, format string, params ...interface{}) {
@@ -212,45 +350,115 @@ func (l *Logger) Error(message string) {
 func (l *Logger) FatalE(err error, context C, message string, params ...interface{}) {
 
 	l.LogC(logLine{err: err, level: CriticalLevel, localCx: context, message: message, params: params})
+	l.exit()
 }
 
 func (l *Logger) FatalC(context C, message string, params ...interface{}) {
 	l.LogC(logLine{level: CriticalLevel, localCx: context, message: message, params: params})
+	l.exit()
 }
 
 func (l *Logger) Fatalf(message string, params ...interface{}) {
 	l.LogC(logLine{level: CriticalLevel, message: message, params: params})
+	l.exit()
 }
 
 func (l *Logger) Fatal(message string) {
 	l.LogC(logLine{level: CriticalLevel, message: message})
+	l.exit()
 }
 
-func flagsInfo(flags int32) string {
-	b := getBuffer()
-	defer putBuffer(b)
+// PanicE logs like FatalE but, instead of exiting, panics with the message
+func (l *Logger) PanicE(err error, context C, message string, params ...interface{}) {
+	l.LogC(logLine{err: err, level: CriticalLevel, localCx: context, message: message, params: params})
+	panic(sprintf(message, params))
+}
+
+func (l *Logger) PanicC(context C, message string, params ...interface{}) {
+	l.LogC(logLine{level: CriticalLevel, localCx: context, message: message, params: params})
+	panic(sprintf(message, params))
+}
 
-	fileNo, funcName := stackInfo()
+func (l *Logger) Panicf(message string, params ...interface{}) {
+	l.LogC(logLine{level: CriticalLevel, message: message, params: params})
+	panic(sprintf(message, params))
+}
+
+func (l *Logger) Panic(message string) {
+	l.LogC(logLine{level: CriticalLevel, message: message})
+	panic(message)
+}
+
+func flagsInfo(flags int32, pc uintptr) (fileNo string, funcName string) {
+	fileNo, funcName = stackInfo(pc)
 
 	if flags&(Llongfile|Lshortfile) != 0 {
 		if flags&Lshortfile != 0 {
 			id := strings.LastIndex(fileNo, "/")
 			fileNo = fileNo[id+1:]
 		}
-		fmt.Fprintf(b, fileNoFlagFormat, fileNo)
+	} else {
+		fileNo = ""
 	}
 
-	if flags&Lmethod != 0 {
-		fmt.Fprintf(b, funcFlagFormat, funcName)
+	if flags&Lmethod == 0 {
+		funcName = ""
 	}
-	return b.String()
+	return fileNo, funcName
+}
+
+// stackInfo returns the file, line and function of pc, or of the external
+// function when pc is 0
+func stackInfo(pc uintptr) (fileNo string, functionName string) {
+	if pc == 0 {
+		var pcs [1]uintptr
+		runtime.Callers(callerDeepLevel, pcs[:])
+		pc = pcs[0]
+	}
+	callersMu.RLock()
+	c, ok := callers[pc]
+	callersMu.RUnlock()
+	if !ok {
+		// CallersFrames, unlike FuncForPC, takes inlined calls into account
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		c = caller{fileNo: frame.File + "." + strconv.Itoa(frame.Line), function: frame.Function}
+		callersMu.Lock()
+		if len(callers) >= maxCallers {
+			clear(callers)
+		}
+		callers[pc] = c
+		callersMu.Unlock()
+	}
+	return c.fileNo, c.function
+}
+
+// caller is the location of a pc, as written with Llongfile and Lmethod
+type caller struct {
+	fileNo   string
+	function string
 }
 
-func stackInfo() (fileNo string, functionName string) {
-	pc := make([]uintptr, 10) // at least 1 entry needed
-	runtime.Callers(callerDeepLevel, pc)
-	f := runtime.FuncForPC(pc[0])
-	file, line := f.FileLine(pc[0])
-	fileNo = fmt.Sprintf("%s.%d", file, line)
-	return fileNo, f.Name()
+// maxCallers bounds the cache of caller locations, that callers of Log
+// functions fill once
+const maxCallers = 4096
+
+var (
+	callersMu sync.RWMutex
+	callers   = map[uintptr]caller{}
+)
+
+// stackTrace returns the calls from the external function up. It is called
+// by format, one level less than stackInfo
+func stackTrace() Stack {
+	pc := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(callerDeepLevel-1, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	stack := make(Stack, 0, n)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, Frame{Func: frame.Function, File: frame.File, Line: frame.Line})
+		if !more {
+			return stack
+		}
+	}
 }
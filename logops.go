@@ -2,84 +2,119 @@ package gologops
 
 import (
 	"bytes"
-	"os"
+	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-type C map[string]string
+// C is a context: fields added to a log line. Values other than strings, such
+// as numbers, booleans, durations, times, []string, nested C or any
+// json.Marshaler, keep their type in JSON lines
+type C map[string]interface{}
 
+// Level is the severity of a line. A logger writes the lines of its level
+// and above; AllLevel writes every line and NoneLevel none. As an
+// encoding.TextUnmarshaler, a Level can be read with flag.TextVar or from
+// JSON
 type Level int
 
 const (
-	allLevel Level = iota
+	AllLevel Level = iota
 	DebugLevel
 	InfoLevel
 	WarnLevel
 	ErrorLevel
 	CriticalLevel
-	noneLevel
+	NoneLevel
 )
 
 var levelNames = [...]string{
-	allLevel:      "ALL",
+	AllLevel:      "ALL",
 	DebugLevel:    "DEBUG",
 	InfoLevel:     "INFO",
 	WarnLevel:     "WARN",
 	ErrorLevel:    "ERROR",
 	CriticalLevel: "FATAL",
-	noneLevel:     "NONE",
+	NoneLevel:     "NONE",
 }
 
-const ErrFieldName = "err"
+// levelAliases are the names, besides levelNames, accepted by ParseLevel
+var levelAliases = map[string]Level{
+	"WARNING":  WarnLevel,
+	"CRITICAL": CriticalLevel,
+	"OFF":      NoneLevel,
+}
+
+// ParseLevel returns the level named s, as written in lines, ignoring case.
+// "WARNING", "CRITICAL" and "OFF" are accepted for WarnLevel, CriticalLevel
+// and NoneLevel
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	for lvl, levelName := range levelNames {
+		if name == levelName {
+			return Level(lvl), nil
+		}
+	}
+	if lvl, ok := levelAliases[name]; ok {
+		return lvl, nil
+	}
+	return 0, fmt.Errorf("gologops: unknown level %q", s)
+}
+
+func (lvl Level) String() string {
+	if lvl < AllLevel || lvl > NoneLevel {
+		return "Level(" + strconv.Itoa(int(lvl)) + ")"
+	}
+	return levelNames[lvl]
+}
+
+func (lvl Level) MarshalText() ([]byte, error) {
+	if lvl < AllLevel || lvl > NoneLevel {
+		return nil, fmt.Errorf("gologops: invalid level %d", int(lvl))
+	}
+	return []byte(levelNames[lvl]), nil
+}
+
+func (lvl *Level) UnmarshalText(text []byte) error {
+	parsed, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lvl = parsed
+	return nil
+}
 
-var (
-	timeFormat       string
-	prefixFormat     string
-	fieldFormat      string
-	errorFormat      string
-	postfixFormat    string
-	fileNoFlagFormat string
-	funcFlagFormat   string
+const (
+	ErrFieldName   = "err"
+	StackFieldName = "stack"
+)
+
+// FieldOrder tells the order of context fields in a line. The err field, if
+// any, always goes first
+type FieldOrder int
+
+const (
+	// LayerOrder writes local context, then dynamic context and then logger
+	// context, from the logger to its oldest parent. Keys are sorted within
+	// every context
+	LayerOrder FieldOrder = iota
+	// SortedOrder writes every field sorted by key
+	SortedOrder
 )
 
 const (
 	Llongfile = 1 << iota
 	Lshortfile
 	Lmethod
+	Lstack    // stack trace in lines of ErrorLevel and above
 	Ldefaults = 0
 )
 
-func init() {
-	format := os.Getenv("LOGOPS_FORMAT")
-	if strings.ToLower(format) == "dev" {
-		setTextFormat()
-	} else {
-		setJSONFormat()
-	}
-}
-
-func setJSONFormat() {
-	timeFormat = time.RFC3339
-	prefixFormat = `{"time":%q, "lvl":%q%s` // time, level and flags (optional)
-	fileNoFlagFormat = `, "file":%q`
-	funcFlagFormat = `, "func":%q`
-	fieldFormat = ", %q:%q"
-	errorFormat = ", %q:%s"
-	postfixFormat = `, "msg":%q}`
-
-}
-
-func setTextFormat() {
-	timeFormat = "15:04:05.000"
-	prefixFormat = "%s %s%s\t" // time, level and flags (optional)
-	fileNoFlagFormat = " %s"
-	funcFlagFormat = " %s"
-	fieldFormat = " [%s=%s]" // key and value
-	errorFormat = " [%s=%s]" // key and value
-	postfixFormat = " %s"    // message
-}
+// defaultFormatter is used by new loggers, as set by LOGOPS_FORMAT and
+// LOGOPS_TIME_FORMAT
+var defaultFormatter = formatterFromEnv()
 
 var bufferPool = sync.Pool{New: func() interface{} { return &bytes.Buffer{} }}
 
@@ -92,9 +127,15 @@ func putBuffer(buffer *bytes.Buffer) {
 }
 
 type logLine struct {
-	level   Level
-	localCx C
-	message string
-	params  []interface{}
-	err     error
+	level     Level
+	localCx   C
+	requestCx C // from the context.Context of the call, if any
+	message   string
+	params    []interface{}
+	err       error
+	stack     Stack // instead of the one taken for Lstack
+	time      time.Time
+	ownTime   bool    // use time, even if zero, instead of now
+	pc        uintptr // program counter of the caller, if already known
+	summary   bool    // count of dropped lines: never dropped, caller only from pc
 }
@@ -54,15 +54,16 @@ func testFormatJSON(t *testing.T, l *Logger, ll logLine, msgWanted string) {
 	)
 
 	start := time.Now()
-	start, err = time.Parse(timeFormat, start.Format(timeFormat))
+	start, err = time.Parse(jsonTimeFormat, start.Format(jsonTimeFormat))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	l.format(&buffer, ll)
+	e := l.entry(ll)
+	l.sinkList()[0].formatter.Load().(formatterValue).Format(&buffer, e)
 	res := buffer.Bytes()
 	end := time.Now()
-	end, err = time.Parse(timeFormat, end.Format(timeFormat))
+	end, err = time.Parse(jsonTimeFormat, end.Format(jsonTimeFormat))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -76,7 +77,7 @@ func testFormatJSON(t *testing.T, l *Logger, ll logLine, msgWanted string) {
 	if err != nil {
 		t.Error(err)
 	}
-	timeStamp, err := time.Parse(timeFormat, timeStr)
+	timeStamp, err := time.Parse(jsonTimeFormat, timeStr)
 	if err != nil {
 		t.Error(err)
 	}
@@ -176,7 +177,7 @@ func testFormatJSON(t *testing.T, l *Logger, ll logLine, msgWanted string) {
 
 func TestSimpleMessageJSON(t *testing.T) {
 	l := NewLogger()
-	for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+	for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 		for _, msgWanted := range stringsForTesting {
 			testFormatJSON(t, l, logLine{level: lvlWanted, message: msgWanted}, msgWanted)
 		}
@@ -185,7 +186,7 @@ func TestSimpleMessageJSON(t *testing.T) {
 
 func TestComplexMessage(t *testing.T) {
 	l := NewLogger()
-	for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+	for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 		for i, text := range stringsForTesting {
 			format := "%s,%#v,%f"
 			params := []interface{}{text, []int{1, i}, float64(i)}
@@ -199,7 +200,7 @@ func TestComplexMessage(t *testing.T) {
 
 func TestLocalContext(t *testing.T) {
 	l := NewLogger()
-	for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+	for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 		for _, msgWanted := range stringsForTesting {
 			testFormatJSON(t, l,
 				logLine{localCx: contextForTesting, level: lvlWanted, message: msgWanted},
@@ -211,7 +212,7 @@ func TestLocalContext(t *testing.T) {
 func TestFuncContext(t *testing.T) {
 	l := NewLogger()
 	l.SetContextFunc(func() C { return contextForTesting })
-	for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+	for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 		for _, msgWanted := range stringsForTesting {
 			testFormatJSON(t, l,
 				logLine{level: lvlWanted, message: msgWanted},
@@ -223,7 +224,7 @@ func TestFuncContext(t *testing.T) {
 func TestLoggerContext(t *testing.T) {
 	l := NewLogger()
 	l.SetContext(contextForTesting)
-	for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+	for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 		for _, msgWanted := range stringsForTesting {
 			testFormatJSON(t, l,
 				logLine{level: lvlWanted, message: msgWanted},
@@ -238,7 +239,7 @@ func TestAllContexts(t *testing.T) { t.Skip("to be implemented") }
 // 	var buffer bytes.Buffer
 // 	l := NewLoggerWithWriter(&buffer)
 // 	ctx := C{"trying": "something"}
-// 	for loggerLevel := allLevel; loggerLevel <= levelMethod; loggerLevel++ {
+// 	for loggerLevel := AllLevel; loggerLevel <= levelMethod; loggerLevel++ {
 // 		l.SetLevel(loggerLevel)
 // 		buffer.Reset()
 // 		method(l, ctx, "a not very long message")
@@ -247,7 +248,7 @@ func TestAllContexts(t *testing.T) { t.Skip("to be implemented") }
 // 			t.Errorf("log not written for method %s when level %s", levelNames[levelMethod], levelNames[loggerLevel])
 // 		}
 // 	}
-// 	for loggerLevel := levelMethod + 1; loggerLevel < noneLevel; loggerLevel++ {
+// 	for loggerLevel := levelMethod + 1; loggerLevel < NoneLevel; loggerLevel++ {
 // 		l.SetLevel(loggerLevel)
 // 		buffer.Reset()
 // 		method(l, ctx, "another short message")
@@ -262,14 +263,15 @@ type complexErr struct {
 	Cause *complexErr
 }
 
-func (ce complexErr) Error() string { return fmt.Sprintf("%+v\n", ce) }
+func (ce complexErr) Error() string { return fmt.Sprintf("%s: %v\n", ce.Text, ce.Cause) }
 
 func testLevelE(t *testing.T, levelMethod Level, method errorLogFunction) {
 	var buffer bytes.Buffer
 	l := NewLoggerWithWriter(&buffer)
+	l.SetExitFunc(nil) // fatal methods must return to be checked
 	ctx := C{"trying": "something"}
 	err := complexErr{"The 1 is another err...", &complexErr{"that nests the number 2 err", nil}}
-	for loggerLevel := allLevel; loggerLevel <= levelMethod; loggerLevel++ {
+	for loggerLevel := AllLevel; loggerLevel <= levelMethod; loggerLevel++ {
 		l.SetLevel(loggerLevel)
 		buffer.Reset()
 		method(l, err, ctx, "a not very long message")
@@ -278,7 +280,7 @@ func testLevelE(t *testing.T, levelMethod Level, method errorLogFunction) {
 			t.Errorf("log not written for method %s when level %s", levelNames[levelMethod], levelNames[loggerLevel])
 		}
 	}
-	for loggerLevel := levelMethod + 1; loggerLevel < noneLevel; loggerLevel++ {
+	for loggerLevel := levelMethod + 1; loggerLevel < NoneLevel; loggerLevel++ {
 		l.SetLevel(loggerLevel)
 		buffer.Reset()
 		method(l, err, ctx, "another short message")
@@ -383,7 +385,7 @@ func TestLoggerAddFlags(t *testing.T) {
 			}
 		}
 
-		for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+		for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 			for _, msgWanted := range stringsForTesting {
 				testFormatJSON(t, l,
 					logLine{localCx: contextForTesting, level: lvlWanted, message: msgWanted},
@@ -420,7 +422,7 @@ func TestLoggerSetFlags(t *testing.T) {
 				t.Error("Expected flags activated = \"Ldefaults\", but it isn't")
 			}
 		}
-		for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+		for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 			for _, msgWanted := range stringsForTesting {
 				testFormatJSON(t, l,
 					logLine{localCx: contextForTesting, level: lvlWanted, message: msgWanted},
@@ -442,7 +444,7 @@ func testLoggerFlags(t *testing.T, flag int32) {
 		_, filename, _, _ := runtime.Caller(0)
 		directory = path.Dir(filename)
 	}
-	for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+	for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 		for _, message := range stringsForTesting {
 			l.SetLevel(lvlWanted)
 			l.Info(message)
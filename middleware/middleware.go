@@ -0,0 +1,157 @@
+// Package middleware provides net/http access logs through a gologops.Logger,
+// with a correlator that identifies every request
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/TDAF/gologops"
+)
+
+const (
+	// DefaultHeader is the header carrying the correlator, as in logops
+	DefaultHeader = "Unica-Correlator"
+	// CorrelatorField is the context field of the correlator
+	CorrelatorField = "corr"
+)
+
+// Options configures HandlerWithOptions
+type Options struct {
+	Header        string        // header with the correlator, DefaultHeader if empty
+	NewCorrelator func() string // for requests without correlator, NewUUID if nil
+}
+
+type loggerKey struct{}
+
+// Handler returns a handler that calls next with a child of l, holding the
+// correlator of the request as context, and logs every request once done.
+// The correlator is taken from the DefaultHeader header, or generated, and
+// echoed back in the response
+func Handler(l *gologops.Logger, next http.Handler) http.Handler {
+	return HandlerWithOptions(l, next, Options{})
+}
+
+// HandlerWithOptions is Handler with a choice of correlator header and generator
+func HandlerWithOptions(l *gologops.Logger, next http.Handler, opts Options) http.Handler {
+	if opts.Header == "" {
+		opts.Header = DefaultHeader
+	}
+	if opts.NewCorrelator == nil {
+		opts.NewCorrelator = NewUUID
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		corr := r.Header.Get(opts.Header)
+		if corr == "" {
+			corr = opts.NewCorrelator()
+		}
+		cx := gologops.C{CorrelatorField: corr}
+		rl := l.With(cx)
+		ctx := context.WithValue(gologops.NewContext(r.Context(), cx), loggerKey{}, rl)
+
+		w.Header().Set(opts.Header, corr)
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			// a panicking handler is logged as failed, then left to net/http
+			p := recover()
+			status := rw.status
+			if p != nil {
+				status = http.StatusInternalServerError
+			}
+			rl.InfoC(gologops.C{
+				"method":  r.Method,
+				"path":    r.URL.Path,
+				"status":  status,
+				"bytes":   rw.bytes,
+				"latency": time.Since(start),
+			}, "%s %s", r.Method, r.URL.Path)
+			if p != nil {
+				panic(p)
+			}
+		}()
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// Logger returns the logger of a request served by Handler, or nil if there
+// is none
+func Logger(ctx context.Context) *gologops.Logger {
+	l, _ := ctx.Value(loggerKey{}).(*gologops.Logger)
+	return l
+}
+
+// NewUUID returns a random (version 4) UUID
+func NewUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// responseWriter keeps the status and size of a response
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over, for websockets and the like, if the
+// original writer can
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return h.Hijack()
+}
+
+// ReadFrom keeps the sendfile and splice paths of the original writer
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.wroteHeader = true
+	var (
+		n   int64
+		err error
+	)
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+	}
+	w.bytes += int(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the original writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
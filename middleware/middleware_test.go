@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TDAF/gologops"
+)
+
+type accessLine struct {
+	Lvl     string
+	Msg     string
+	Corr    string
+	Method  string
+	Path    string
+	Status  int
+	Bytes   int
+	Latency time.Duration
+	Inner   string
+}
+
+func decodeLines(t *testing.T, buffer *bytes.Buffer) []accessLine {
+	var lines []accessLine
+	dec := json.NewDecoder(buffer)
+	for dec.More() {
+		var line accessLine
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestHandler(t *testing.T) {
+	var buffer bytes.Buffer
+	l := gologops.NewLoggerWithFormatter(&buffer, gologops.JSONFormatter{})
+	h := Handler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		Logger(r.Context()).InfoC(gologops.C{"inner": "yes"}, "handling")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+
+	req := httptest.NewRequest("POST", "/tea?x=1", nil)
+	req.Header.Set(DefaultHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(DefaultHeader); got != "abc-123" {
+		t.Errorf("correlator not echoed: %q", got)
+	}
+	lines := decodeLines(t, &buffer)
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %d: %+v", len(lines), lines)
+	}
+	if lines[0].Corr != "abc-123" || lines[0].Inner != "yes" {
+		t.Errorf("handler line without correlator: %+v", lines[0])
+	}
+	access := lines[1]
+	if access.Corr != "abc-123" || access.Method != "POST" || access.Path != "/tea" ||
+		access.Status != http.StatusTeapot || access.Bytes != len("short and stout") ||
+		access.Latency < time.Millisecond || access.Lvl != "INFO" || access.Msg != "POST /tea" {
+		t.Errorf("unexpected access line: %+v", access)
+	}
+}
+
+func TestHandlerGeneratesCorrelator(t *testing.T) {
+	var buffer bytes.Buffer
+	l := gologops.NewLoggerWithFormatter(&buffer, gologops.JSONFormatter{})
+	h := HandlerWithOptions(l, http.NotFoundHandler(), Options{Header: "X-Request-Id"})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+
+	corr := rec.Header().Get("X-Request-Id")
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(corr) {
+		t.Errorf("generated correlator is not a UUID: %q", corr)
+	}
+	lines := decodeLines(t, &buffer)
+	if len(lines) != 1 || lines[0].Corr != corr || lines[0].Status != http.StatusNotFound {
+		t.Errorf("unexpected access lines: %+v", lines)
+	}
+}
+
+func TestHandlerRequestContext(t *testing.T) {
+	var buffer bytes.Buffer
+	l := gologops.NewLoggerWithFormatter(&buffer, gologops.JSONFormatter{})
+	h := HandlerWithOptions(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.InfoCtx(r.Context(), nil, "through the context.Context")
+	}), Options{NewCorrelator: func() string { return "fixed" }})
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	lines := decodeLines(t, &buffer)
+	if len(lines) != 2 || lines[0].Corr != "fixed" || lines[1].Status != http.StatusOK {
+		t.Errorf("unexpected lines: %+v", lines)
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	var buffer bytes.Buffer
+	l := gologops.NewLoggerWithFormatter(&buffer, gologops.JSONFormatter{})
+	h := Handler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("panic not passed on: %v", r)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	lines := decodeLines(t, &buffer)
+	if len(lines) != 1 || lines[0].Status != http.StatusInternalServerError {
+		t.Errorf("want an access line with status 500, got %+v", lines)
+	}
+}
+
+func TestHandlerHijack(t *testing.T) {
+	var buffer bytes.Buffer
+	l := gologops.NewLoggerWithFormatter(&buffer, gologops.JSONFormatter{})
+	srv := httptest.NewServer(Handler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hijacked" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := w.(http.Hijacker).Hijack(); err != http.ErrNotSupported {
+			t.Errorf("Hijack of a writer without it: want %v, got %v", http.ErrNotSupported, err)
+		}
+		io.Copy(w, strings.NewReader("copied"))
+	})).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != "copied" {
+		t.Errorf("ReadFrom: unexpected body %q", rec.Body.String())
+	}
+}
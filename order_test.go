@@ -0,0 +1,57 @@
+package gologops
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// staticTime is a time layout without verbs, so every line has the same time
+const staticTime = "static"
+
+func testFieldOrder(t *testing.T, order FieldOrder, f Formatter, want string) {
+	var buffer bytes.Buffer
+	parent := NewLoggerWithFormatter(&buffer, f)
+	parent.SetFieldOrder(order)
+	parent.SetContext(C{"z": "parent", "p": "parent", "b": "parent"})
+	parent.SetContextFunc(func() C { return C{"y": "func", "d": "func", "b": "func"} })
+	l := parent.With(C{"x": "child", "c": "child", "p": "child"})
+
+	l.InfoC(C{"w": "local", "a": "local", "x": "local"}, "msg")
+	first := buffer.String()
+	if first != want {
+		t.Errorf("order %d: want %q, got %q", order, want, first)
+	}
+	for i := 0; i < 100; i++ {
+		buffer.Reset()
+		l.InfoC(C{"w": "local", "a": "local", "x": "local"}, "msg")
+		if buffer.String() != first {
+			t.Fatalf("order %d: line %d differs, want %q, got %q", order, i, first, buffer.String())
+		}
+	}
+}
+
+func TestLayerOrder(t *testing.T) {
+	testFieldOrder(t, LayerOrder, TextFormatter{TimeFormat: staticTime},
+		"static INFO\t [a=local] [w=local] [x=local] [b=func] [d=func] [y=func]"+
+			" [c=child] [p=child] [z=parent] msg\n")
+	testFieldOrder(t, LayerOrder, JSONFormatter{TimeFormat: staticTime},
+		`{"time":"static", "lvl":"INFO", "a":"local", "w":"local", "x":"local", "b":"func", "d":"func", "y":"func",`+
+			` "c":"child", "p":"child", "z":"parent", "msg":"msg"}`+"\n")
+}
+
+func TestSortedOrder(t *testing.T) {
+	testFieldOrder(t, SortedOrder, TextFormatter{TimeFormat: staticTime},
+		"static INFO\t [a=local] [b=func] [c=child] [d=func] [p=child] [w=local] [x=local]"+
+			" [y=func] [z=parent] msg\n")
+}
+
+func TestErrFieldFirst(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, TextFormatter{TimeFormat: staticTime})
+	l.SetFieldOrder(SortedOrder)
+	l.ErrorE(testingNotJSONableNError{}, C{"a": "b"}, "msg")
+	if !strings.HasPrefix(buffer.String(), "static ERROR\t [err=") {
+		t.Errorf("err is not the first field: %q", buffer.String())
+	}
+}
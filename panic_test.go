@@ -0,0 +1,89 @@
+package gologops
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testPanic(t *testing.T, name string, panicWanted string, f func()) {
+	defer func() {
+		r := recover()
+		if r != panicWanted {
+			t.Errorf("%s: panic value: want %q, got %#v", name, panicWanted, r)
+		}
+	}()
+	f()
+	t.Errorf("%s: did not panic", name)
+}
+
+func TestPanic(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithWriter(&buffer)
+	l.SetExitFunc(func(int) { t.Error("panic should not exit") })
+	panicFuncs := map[string]func(){
+		"Panic":  func() { l.Panic("bye 1") },
+		"Panicf": func() { l.Panicf("bye %d", 1) },
+		"PanicC": func() { l.PanicC(C{"a": "b"}, "bye %d", 1) },
+		"PanicE": func() { l.PanicE(testingNotJSONableNError{}, nil, "bye %d", 1) },
+	}
+	for name, f := range panicFuncs {
+		buffer.Reset()
+		testPanic(t, name, "bye 1", f)
+		obj := decodeLine(t, &buffer)
+		if obj["lvl"] != levelNames[CriticalLevel] || obj["msg"] != "bye 1" {
+			t.Errorf("%s: unexpected line %v", name, obj)
+		}
+	}
+}
+
+func TestGlobalPanic(t *testing.T) {
+	var buffer bytes.Buffer
+	defer SetWriter(defaultLogger.sinkList()[0].writer)
+	SetWriter(&buffer)
+	testPanic(t, "Panicf", "bye 2", func() { Panicf("bye %d", 2) })
+	if buffer.Len() == 0 {
+		t.Error("panic line not written")
+	}
+}
+
+func TestStackFlag(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetFlags(Lstack)
+
+	l.InfoC(C{StackFieldName: "kept"}, "no stack below ErrorLevel")
+	obj := decodeLine(t, &buffer)
+	if obj[StackFieldName] != "kept" {
+		t.Errorf("stack field at InfoLevel: %v", obj[StackFieldName])
+	}
+
+	l.ErrorC(C{StackFieldName: "replaced"}, "with stack")
+	var line struct {
+		Stack []Frame
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+		t.Fatalf("%v in %s", err, buffer.Bytes())
+	}
+	if len(line.Stack) == 0 {
+		t.Fatalf("missing stack in %s", buffer.Bytes())
+	}
+	if top := line.Stack[0]; top.Func != "github.com/TDAF/gologops.TestStackFlag" ||
+		!strings.HasSuffix(top.File, "panic_test.go") || top.Line == 0 {
+		t.Errorf("first frame should be this test, got %+v", top)
+	}
+	if strings.Count(buffer.String(), `"stack"`) != 1 {
+		t.Errorf("stack field repeated in %s", buffer.Bytes())
+	}
+}
+
+func TestStackText(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, TextFormatter{})
+	l.SetFlags(Lstack)
+	l.Error("with stack")
+	if !strings.Contains(buffer.String(), "[stack=github.com/TDAF/gologops.TestStackText (") {
+		t.Errorf("missing stack in %q", buffer.String())
+	}
+}
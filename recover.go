@@ -0,0 +1,65 @@
+package gologops
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Recover stops a panic and logs it at CriticalLevel, with the stack of the
+// panicking goroutine. It must be deferred directly:
+//
+//	defer l.Recover()
+func (l *Logger) Recover() {
+	if r := recover(); r != nil {
+		l.logPanic(r)
+	}
+}
+
+// RecoverAndRepanic logs a panic like Recover and then panics again with the
+// same value. It must be deferred directly
+func (l *Logger) RecoverAndRepanic() {
+	if r := recover(); r != nil {
+		l.logPanic(r)
+		panic(r)
+	}
+}
+
+func (l *Logger) logPanic(r interface{}) {
+	stack, pc := panicStack()
+	ll := logLine{level: CriticalLevel, message: "recovered panic: %v", params: []interface{}{r}, stack: stack, pc: pc}
+	if err, ok := r.(error); ok {
+		ll.err = err
+	}
+	l.LogC(ll)
+}
+
+// panicStack returns the stack of a panicking goroutine from the call that
+// panicked up, and a pc of that call for the file and func of the line
+func panicStack() (Stack, uintptr) {
+	pc := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(1, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	var (
+		stack Stack
+		pcs   []uintptr
+	)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, Frame{Func: frame.Function, File: frame.File, Line: frame.Line})
+		pcs = append(pcs, frame.PC+1) // as a return address, as stackInfo takes it
+		if frame.Function == "runtime.gopanic" {
+			stack, pcs = stack[:0], pcs[:0]
+		}
+		if !more {
+			break
+		}
+	}
+	// runtime errors, such as nil dereferences, panic from inside the runtime
+	for len(stack) > 1 && strings.HasPrefix(stack[0].Func, "runtime.") {
+		stack, pcs = stack[1:], pcs[1:]
+	}
+	if len(pcs) == 0 {
+		return stack, 0
+	}
+	return stack, pcs[0]
+}
@@ -0,0 +1,118 @@
+package gologops
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recoveredLine struct {
+	Lvl   string
+	Msg   string
+	Err   json.RawMessage
+	Stack []Frame
+	Svc   string
+	Host  string
+}
+
+func panickingWorker(l *Logger, value interface{}) {
+	defer l.Recover()
+	panic(value)
+}
+
+func TestRecover(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetContext(C{"svc": "worker"})
+	l.SetContextFunc(func() C { return C{"host": "localhost"} })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		panickingWorker(l, "something bad")
+	}()
+	wg.Wait()
+
+	var line recoveredLine
+	if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+		t.Fatalf("%v in %s", err, buffer.Bytes())
+	}
+	if line.Lvl != levelNames[CriticalLevel] || line.Msg != "recovered panic: something bad" {
+		t.Errorf("unexpected line %s", buffer.Bytes())
+	}
+	if line.Svc != "worker" || line.Host != "localhost" {
+		t.Errorf("missing logger context in %s", buffer.Bytes())
+	}
+	if len(line.Stack) == 0 || line.Stack[0].Func != "github.com/TDAF/gologops.panickingWorker" {
+		t.Errorf("stack should start at the panic, got %+v", line.Stack)
+	}
+}
+
+func TestRecoverRuntimeError(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	func() {
+		defer l.Recover()
+		var m map[string]int
+		m["boom"] = 1
+	}()
+
+	var line recoveredLine
+	if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+		t.Fatalf("%v in %s", err, buffer.Bytes())
+	}
+	if !strings.Contains(line.Msg, "assignment to entry in nil map") || line.Err == nil {
+		t.Errorf("runtime error not logged as error: %s", buffer.Bytes())
+	}
+	if len(line.Stack) == 0 || !strings.HasPrefix(line.Stack[0].Func, "github.com/TDAF/gologops.TestRecoverRuntimeError") {
+		t.Errorf("stack should start at the panic, got %+v", line.Stack)
+	}
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	errPanic := errors.New("again")
+	defer func() {
+		if r := recover(); r != errPanic {
+			t.Errorf("panic value: want %v, got %v", errPanic, r)
+		}
+		if !strings.Contains(buffer.String(), "recovered panic: again") {
+			t.Errorf("panic not logged: %s", buffer.Bytes())
+		}
+	}()
+	func() {
+		defer l.RecoverAndRepanic()
+		panic(errPanic)
+	}()
+}
+
+func TestRecoverNoPanic(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	func() {
+		defer l.Recover()
+	}()
+	if buffer.Len() > 0 {
+		t.Errorf("logged without panic: %s", buffer.Bytes())
+	}
+}
+
+func TestRecoverCaller(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetFlags(Lshortfile | Lmethod)
+	panickingWorker(l, "with flags")
+
+	var line struct{ File, Func string }
+	if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+		t.Fatalf("%v in %s", err, buffer.Bytes())
+	}
+	if !strings.HasPrefix(line.File, "recover_test.go.") || line.Func != "github.com/TDAF/gologops.panickingWorker" {
+		t.Errorf("file and func should be the panicking function, got %s", buffer.Bytes())
+	}
+}
@@ -0,0 +1,304 @@
+// Package rotate provides a file writer that rolls over by size or by time,
+// to be used as the writer of a gologops.Logger where logrotate is not around
+package rotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const compressSuffix = ".gz"
+
+// Options tells when a File rolls over and what happens to old files
+type Options struct {
+	MaxSize     int64         // bytes before rolling over, no limit if 0
+	Interval    time.Duration // roll over at multiples of Interval since the zero time, UTC; never if 0
+	MaxBackups  int           // backups kept, path.1 the newest; all of them if 0
+	Compress    bool          // gzip backups, as path.1.gz
+	ReopenOnHUP bool          // reopen path on SIGHUP, for external rotation
+}
+
+// File is an io.Writer appending to a file that is renamed to path.1 when it
+// rolls over, path.1 to path.2 and so on. It is safe for concurrent use by
+// several loggers
+type File struct {
+	path string
+	opts Options
+	now  func() time.Time
+
+	mu           sync.Mutex
+	file         *os.File // nil if closed, or if opening it again failed
+	closed       bool
+	size         int64
+	nextRotation time.Time
+	compressed   chan error // result of the backup being compressed, if any
+
+	signals chan os.Signal
+	done    chan struct{}
+}
+
+// Open opens or creates path to append to it
+func Open(path string, opts Options) (*File, error) {
+	f := &File{path: path, opts: opts, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if opts.ReopenOnHUP {
+		f.signals = make(chan os.Signal, 1)
+		f.done = make(chan struct{})
+		signal.Notify(f.signals, syscall.SIGHUP)
+		go f.handleSignals()
+	}
+	return f, nil
+}
+
+func (f *File) handleSignals() {
+	for {
+		select {
+		case <-f.signals:
+			f.Reopen()
+		case <-f.done:
+			return
+		}
+	}
+}
+
+// open must be called with f.mu held, or before f is shared
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	if f.opts.Interval > 0 {
+		f.nextRotation = f.now().Truncate(f.opts.Interval).Add(f.opts.Interval)
+	}
+	return nil
+}
+
+// ready opens path again if a failed rotation or reopen left f without a
+// file. It must be called with f.mu held
+func (f *File) ready() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file == nil {
+		return f.open()
+	}
+	return nil
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ready(); err != nil {
+		return 0, err
+	}
+	if f.mustRotate(len(p)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *File) mustRotate(next int) bool {
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(next) > f.opts.MaxSize {
+		return true
+	}
+	return f.opts.Interval > 0 && !f.now().Before(f.nextRotation)
+}
+
+// Rotate rolls over now
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ready(); err != nil {
+		return err
+	}
+	return f.rotate()
+}
+
+// rotate moves the file to path.1 and opens path again. Whatever fails, path
+// is opened again for the next writes. A backup is compressed in the
+// background; an error compressing it is returned by the next rotation or by
+// Close
+func (f *File) rotate() error {
+	compressErr := f.waitCompress()
+	err := f.file.Close()
+	f.file = nil // even if Close failed, so the next write opens path again
+	if err != nil {
+		return err
+	}
+	first := f.path + ".1"
+	err = f.shiftBackups()
+	if err == nil {
+		err = os.Rename(f.path, first)
+	}
+	if openErr := f.open(); err == nil {
+		err = openErr
+	}
+	if err != nil {
+		return err
+	}
+	if f.opts.Compress {
+		compressed := make(chan error, 1)
+		f.compressed = compressed
+		go func() { compressed <- compress(first) }()
+	}
+	return compressErr
+}
+
+// waitCompress waits for the backup being compressed, if any, and returns the
+// error compressing it
+func (f *File) waitCompress() error {
+	if f.compressed == nil {
+		return nil
+	}
+	err := <-f.compressed
+	f.compressed = nil
+	return err
+}
+
+// shiftBackups renames path.n to path.n+1, from the oldest one, removing
+// those beyond MaxBackups
+func (f *File) shiftBackups() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+	numbers := make([]int, 0, len(backups))
+	for n := range backups {
+		numbers = append(numbers, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+	for _, n := range numbers {
+		name := backups[n]
+		if f.opts.MaxBackups > 0 && n >= f.opts.MaxBackups {
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+			continue
+		}
+		suffix := strings.TrimPrefix(name, fmt.Sprintf("%s.%d", f.path, n))
+		if err := os.Rename(name, fmt.Sprintf("%s.%d%s", f.path, n+1, suffix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backups returns the names of the existing backups by number. The directory
+// is listed rather than globbed, since path may have glob metacharacters
+func (f *File) backups() (map[int]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(f.path) + "."
+	backups := make(map[int]string)
+	for _, entry := range entries {
+		number, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(number, compressSuffix)); err == nil && n > 0 {
+			backups[n] = f.path + "." + number
+		}
+	}
+	return backups, nil
+}
+
+// compress replaces name with a gzipped name.gz
+func compress(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// Reopen closes the file and opens path again, which may be a new file if
+// the old one was moved away
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file != nil {
+		err := f.file.Close()
+		f.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	return f.open()
+}
+
+func (f *File) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ready(); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file, once the backup being compressed, if any, is done,
+// and stops listening to SIGHUP
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.done)
+	}
+	err := f.waitCompress()
+	if f.file != nil {
+		if closeErr := f.file.Close(); err == nil {
+			err = closeErr
+		}
+		f.file = nil
+	}
+	return err
+}
@@ -0,0 +1,252 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TDAF/gologops"
+)
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func readGzip(t *testing.T, name string) string {
+	t.Helper()
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotateBySize(t *testing.T) {
+	// glob metacharacters in the name must not get in the way of backups
+	for _, name := range []string{"app.log", "app[.log", "app[1].log"} {
+		path := filepath.Join(t.TempDir(), name)
+		f, err := Open(path, Options{MaxSize: 10, MaxBackups: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+			if _, err := f.Write([]byte(line)); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		f.Close()
+		if got := readFile(t, path); got != "dddddd\n" {
+			t.Errorf("%s: current file: %q", name, got)
+		}
+		if got := readFile(t, path+".1"); got != "cccccc\n" {
+			t.Errorf("%s: first backup: %q", name, got)
+		}
+		if got := readFile(t, path+".2"); got != "bbbbbb\n" {
+			t.Errorf("%s: second backup: %q", name, got)
+		}
+		if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+			t.Errorf("%s: backup beyond MaxBackups kept: %v", name, err)
+		}
+	}
+}
+
+func TestRotateCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := Open(path, Options{Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		f.Write([]byte(line))
+		if err := f.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil { // waits for the last compression
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{".1.gz": "third\n", ".2.gz": "second\n", ".3.gz": "first\n"} {
+		if got := readGzip(t, path+name); got != want {
+			t.Errorf("backup %s: want %q, got %q", name, want, got)
+		}
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("uncompressed backup kept: %v", err)
+	}
+}
+
+func TestRotateByInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	now := time.Date(2016, 1, 2, 23, 59, 0, 0, time.UTC)
+	f := &File{path: path, opts: Options{Interval: 24 * time.Hour}, now: func() time.Time { return now }}
+	if err := f.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("day 2\n"))
+	now = now.Add(30 * time.Second)
+	f.Write([]byte("still day 2\n"))
+	now = now.Add(time.Minute)
+	f.Write([]byte("day 3\n"))
+
+	if got := readFile(t, path+".1"); got != "day 2\nstill day 2\n" {
+		t.Errorf("backup: %q", got)
+	}
+	if got := readFile(t, path); got != "day 3\n" {
+		t.Errorf("current file: %q", got)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write([]byte("before\n"))
+	if err := os.Rename(path, path+".moved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("after\n"))
+	if got := readFile(t, path); got != "after\n" {
+		t.Errorf("reopened file: %q", got)
+	}
+	if got := readFile(t, path+".moved"); got != "before\n" {
+		t.Errorf("moved file: %q", got)
+	}
+}
+
+func TestSharedByLoggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := Open(path, Options{MaxSize: 4096})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggers := []*gologops.Logger{
+		gologops.NewLoggerWithFormatter(f, gologops.JSONFormatter{}),
+		gologops.NewLoggerWithFormatter(f, gologops.TextFormatter{}),
+	}
+	var wg sync.WaitGroup
+	for _, l := range loggers {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(l *gologops.Logger) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					l.Infof("line %d", j)
+				}
+			}(l)
+		}
+	}
+	wg.Wait()
+	f.Close()
+
+	lines := 0
+	backups, _ := filepath.Glob(path + "*")
+	for _, name := range backups {
+		content := readFile(t, name)
+		if len(content) > 4096 {
+			t.Errorf("%s is bigger than MaxSize: %d", name, len(content))
+		}
+		for _, line := range strings.SplitAfter(content, "\n") {
+			if line == "" {
+				continue
+			}
+			if !strings.HasSuffix(line, "\n") || !strings.Contains(line, "line ") {
+				t.Errorf("broken line in %s: %q", name, line)
+			}
+			lines++
+		}
+	}
+	if lines != 800 {
+		t.Errorf("lines: want 800, got %d", lines)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	f, err := Open(filepath.Join(t.TempDir(), "app.log"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := f.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("write after close: want %v, got %v", os.ErrClosed, err)
+	}
+}
+
+func TestFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := Open(path, Options{MaxSize: 10, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a non-empty directory where the backup goes cannot be replaced
+	if err := os.MkdirAll(filepath.Join(path+".1", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("aaaaaa\n"))
+	if _, err := f.Write([]byte("bbbbbb\n")); err == nil {
+		t.Fatalf("rotation over a directory should fail")
+	}
+
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("cccccc\n")); err != nil {
+		t.Fatalf("write once the cause is removed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := readFile(t, path) + readFile(t, path+".1"); got != "cccccc\naaaaaa\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if err := f.Close(); err != os.ErrClosed {
+		t.Errorf("second Close: want %v, got %v", os.ErrClosed, err)
+	}
+}
+
+func TestFailedClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, op := range []func() error{f.Rotate, f.Reopen} {
+		f.file.Close() // makes the Close of the file fail
+		if err := op(); err == nil {
+			t.Fatalf("failed Close not reported")
+		}
+		if _, err := f.Write([]byte("line\n")); err != nil {
+			t.Fatalf("write after a failed Close: %v", err)
+		}
+	}
+	if got := readFile(t, path); got != "line\nline\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
@@ -0,0 +1,101 @@
+package gologops
+
+import (
+	"sync"
+	"time"
+)
+
+// Sampling limits the lines written for a repeated call. Of the lines with the
+// same level and message, taken before its params are applied, the First of
+// every Interval are written and then one of every Thereafter; with
+// Thereafter 0 the rest are dropped. With Summary, an INFO line with the count
+// of dropped lines is written at the end of every interval dropping any
+type Sampling struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+	Summary    bool
+}
+
+// SampledFieldName is the field with the count of dropped lines in the
+// summary written with Sampling.Summary
+const SampledFieldName = "sampled"
+
+type sampleKey struct {
+	level   Level
+	message string
+}
+
+type sampler struct {
+	Sampling
+	l       *Logger // writes the summary
+	mu      sync.Mutex
+	start   time.Time // of the current interval
+	counts  map[sampleKey]int
+	dropped int  // since the last summary
+	pending bool // a summary is scheduled
+}
+
+// SetSampling applies s to the lines of l and every logger sharing its
+// output. Sampling runs before the line is formatted, so dropped lines cost
+// only their counting. A zero Interval turns sampling off
+func (l *Logger) SetSampling(s Sampling) {
+	var smp *sampler
+	if s.Interval > 0 {
+		smp = &sampler{Sampling: s, l: l, counts: map[sampleKey]int{}}
+	}
+	l.sampler.Store(smp)
+}
+
+// SampledOut returns the number of lines dropped by sampling since l was
+// created
+func (l *Logger) SampledOut() uint64 {
+	return l.sampledOut.Load()
+}
+
+// sampled tells whether ll is dropped by the sampling of l
+func (l *Logger) sampled(ll logLine) bool {
+	s, _ := l.sampler.Load().(*sampler)
+	if s == nil || ll.summary || s.keep(ll) {
+		return false
+	}
+	l.sampledOut.Add(1)
+	return true
+}
+
+func (s *sampler) keep(ll logLine) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.start) >= s.Interval {
+		s.start = now
+		clear(s.counts)
+	}
+	key := sampleKey{ll.level, ll.message}
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.First || (s.Thereafter > 0 && (n-s.First)%s.Thereafter == 0) {
+		return true
+	}
+	s.dropped++
+	if s.Summary && !s.pending {
+		s.pending = true
+		time.AfterFunc(s.start.Add(s.Interval).Sub(now), s.summary)
+	}
+	return false
+}
+
+func (s *sampler) summary() {
+	s.mu.Lock()
+	dropped := s.dropped
+	s.dropped = 0
+	s.pending = false
+	s.mu.Unlock()
+	s.l.LogC(logLine{
+		level:   InfoLevel,
+		localCx: C{SampledFieldName: dropped},
+		message: "sampled out %d lines",
+		params:  []interface{}{dropped},
+		summary: true,
+	})
+}
@@ -0,0 +1,91 @@
+package gologops
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testingLockedBuffer is a buffer written and read from several goroutines
+type testingLockedBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *testingLockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *testingLockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.String()
+}
+
+func TestSampling(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, TextFormatter{TimeFormat: staticTime})
+	l.SetSampling(Sampling{Interval: time.Hour, First: 3, Thereafter: 10})
+	for i := 0; i < 100; i++ {
+		l.Infof("hot %d", i)
+		l.Debug("hot %d") // same message, another level
+	}
+	l.Warn("other")
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	// the first 3, then the 13th, 23th ... 93th of each level
+	if want := 2*(3+9) + 1; len(lines) != want {
+		t.Errorf("want %d lines, got %d: %q", want, len(lines), lines)
+	}
+	if !strings.HasSuffix(lines[4], "hot 2") || !strings.HasSuffix(lines[6], "hot 12") {
+		t.Errorf("unexpected lines written: %q", lines[:8])
+	}
+	if got := l.SampledOut(); got != 2*(100-12) {
+		t.Errorf("sampled out: want %d, got %d", 2*(100-12), got)
+	}
+
+	l.SetSampling(Sampling{})
+	buffer.Reset()
+	l.Infof("hot %d", 0)
+	if buffer.Len() == 0 {
+		t.Errorf("sampling not turned off")
+	}
+}
+
+func TestSamplingInterval(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetSampling(Sampling{Interval: 50 * time.Millisecond, First: 1})
+	l.Info("msg")
+	l.Info("msg")
+	time.Sleep(60 * time.Millisecond)
+	l.Info("msg")
+	if got := strings.Count(buffer.String(), "\n"); got != 2 {
+		t.Errorf("want a line per interval, got %q", buffer.String())
+	}
+}
+
+func TestSamplingSummary(t *testing.T) {
+	w := &testingLockedBuffer{}
+	l := NewLoggerWithFormatter(w, JSONFormatter{})
+	l.SetFlags(Lshortfile | Lmethod)
+	l.SetSampling(Sampling{Interval: 20 * time.Millisecond, First: 1, Summary: true})
+	for i := 0; i < 5; i++ {
+		l.Info("msg")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(w.String(), `"sampled":4`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("no summary line: %q", w.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	if summary := lines[len(lines)-1]; strings.Contains(summary, `"file"`) || strings.Contains(summary, `"func"`) {
+		t.Errorf("summary line with the caller of a timer: %s", summary)
+	}
+}
@@ -0,0 +1,99 @@
+package gologops
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Sink is an output of a Logger. Lines of Level or above, once they pass the
+// level of the logger, are written to Writer as produced by Formatter
+type Sink struct {
+	Name      string // identifies the sink in a SinkError
+	Writer    io.Writer
+	Formatter Formatter // the one selected by LOGOPS_FORMAT when nil
+	Level     Level
+}
+
+// SinkError is the error of writing a line to one of the sinks of a logger
+type SinkError struct {
+	Sink string // name of the sink, or its position if it has no name
+	Err  error
+}
+
+func (e *SinkError) Error() string {
+	return "gologops: sink " + e.Sink + ": " + e.Err.Error()
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+// sink is a Sink in use. The writer, formatter and level of the first sink of
+// a logger change with SetWriter, SetFormatter and SetSinkLevel
+type sink struct {
+	name      string
+	level     int32
+	formatter atomic.Value
+	mu        sync.Mutex
+	writer    io.Writer
+}
+
+func newSink(s Sink, position int) *sink {
+	if s.Formatter == nil {
+		s.Formatter = defaultFormatter
+	}
+	if s.Name == "" {
+		s.Name = strconv.Itoa(position)
+	}
+	ns := &sink{name: s.Name, level: int32(s.Level), writer: s.Writer}
+	ns.formatter.Store(formatterValue{s.Formatter})
+	return ns
+}
+
+func (s *sink) accepts(lvl Level) bool {
+	return Level(atomic.LoadInt32(&s.level)) <= lvl
+}
+
+// write formats e and writes it, handing the buffer over to writers that
+// take it
+func (s *sink) write(e *Entry) error {
+	b := getBuffer()
+	s.formatter.Load().(formatterValue).Format(b, e)
+	s.mu.Lock()
+	if bw, ok := s.writer.(bufferWriter); ok {
+		s.mu.Unlock()
+		return bw.writeBuffer(b, e.Level) // b is not ours anymore
+	}
+	_, err := s.writer.Write(b.Bytes())
+	s.mu.Unlock()
+	putBuffer(b)
+	return err
+}
+
+// flush pushes pending data of the writer, when it knows how
+func (s *sink) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch w := s.writer.(type) {
+	case flusher:
+		w.Flush()
+	case syncer:
+		w.Sync()
+	}
+}
+
+// AddSink makes l, and every logger sharing its output, write also to s.
+// Write errors of a logger with several sinks are reported as a SinkError
+// per failed sink, joined with errors.Join
+func (l *Logger) AddSink(s Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sinks := l.sinkList()
+	l.sinks.Store(append(sinks[:len(sinks):len(sinks)], newSink(s, len(sinks))))
+}
+
+func (l *Logger) sinkList() []*sink {
+	return l.sinks.Load().([]*sink)
+}
@@ -0,0 +1,87 @@
+package gologops
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSinks(t *testing.T) {
+	var text, json bytes.Buffer
+	l := NewLoggerWithFormatter(&text, TextFormatter{TimeFormat: staticTime})
+	l.SetLevel(DebugLevel)
+	l.AddSink(Sink{Writer: &json, Formatter: JSONFormatter{}, Level: WarnLevel})
+
+	l.Debug("debug")
+	l.Warn("warn")
+	if got := strings.Count(text.String(), "\n"); got != 2 {
+		t.Errorf("text sink: want 2 lines, got %q", text.String())
+	}
+	obj := decodeLine(t, &json)
+	if obj["msg"] != "warn" {
+		t.Errorf("JSON sink: want only the warn line, got %v", obj)
+	}
+	if json.Len() > 0 {
+		t.Errorf("JSON sink: unexpected lines %q", json.String())
+	}
+
+	l.SetLevel(ErrorLevel)
+	l.Warn("filtered")
+	if strings.Contains(text.String()+json.String(), "filtered") {
+		t.Errorf("level of the logger should apply to every sink")
+	}
+}
+
+func TestSinkLevel(t *testing.T) {
+	var json, text bytes.Buffer
+	l := NewLoggerWithFormatter(&json, JSONFormatter{})
+	l.SetSinkLevel(WarnLevel)
+	l.AddSink(Sink{Writer: &text, Formatter: TextFormatter{TimeFormat: staticTime}, Level: DebugLevel})
+
+	l.Debug("debug")
+	l.Warn("warn")
+	if got := strings.Count(text.String(), "\n"); got != 2 {
+		t.Errorf("text sink: want 2 lines, got %q", text.String())
+	}
+	if obj := decodeLine(t, &json); obj["msg"] != "warn" || json.Len() > 0 {
+		t.Errorf("first sink: want only the warn line, got %v %q", obj, json.String())
+	}
+}
+
+func TestSinksShared(t *testing.T) {
+	var first, second bytes.Buffer
+	parent := NewLoggerWithFormatter(&first, JSONFormatter{})
+	child := parent.With(C{"a": "b"})
+	child.AddSink(Sink{Writer: &second, Formatter: JSONFormatter{}})
+	parent.Info("msg")
+	if first.Len() == 0 || second.Len() == 0 {
+		t.Errorf("sink added by child not used by parent: %q %q", first.String(), second.String())
+	}
+}
+
+func TestSinkErrors(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(testingBadWriter{}, JSONFormatter{})
+	l.AddSink(Sink{Writer: &buffer})
+	l.AddSink(Sink{Name: "bad", Writer: testingBadWriter{}})
+
+	err := l.LogC(logLine{level: InfoLevel, message: "msg"})
+	if buffer.Len() == 0 {
+		t.Errorf("failing sinks should not stop the others")
+	}
+	if !errors.Is(err, errTestingBadWriter) {
+		t.Errorf("want %v, got %v", errTestingBadWriter, err)
+	}
+	var names []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var sinkErr *SinkError
+		if !errors.As(err, &sinkErr) {
+			t.Fatalf("want a SinkError, got %#v", err)
+		}
+		names = append(names, sinkErr.Sink)
+	}
+	if strings.Join(names, ",") != "0,bad" {
+		t.Errorf("failed sinks: want 0,bad, got %v", names)
+	}
+}
@@ -0,0 +1,129 @@
+package gologops
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHandler is a slog.Handler writing through a Logger. Attributes become
+// local context of the line, groups nested C. Context added to the
+// context.Context with NewContext is written too
+type SlogHandler struct {
+	l      *Logger
+	attrs  C        // from WithAttrs, already nested in their groups
+	groups []string // open groups, from WithGroup
+}
+
+// NewSlogHandler returns a slog.Handler for l, to be used as
+//
+//	slog.New(gologops.NewSlogHandler(l))
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{l: l}
+}
+
+// SlogLevel returns the Level of a slog level. Levels between two slog
+// levels are rounded down, and levels above slog.LevelError become
+// CriticalLevel
+func SlogLevel(lvl slog.Level) Level {
+	switch {
+	case lvl < slog.LevelInfo:
+		return DebugLevel
+	case lvl < slog.LevelWarn:
+		return InfoLevel
+	case lvl < slog.LevelError:
+		return WarnLevel
+	case lvl == slog.LevelError:
+		return ErrorLevel
+	default:
+		return CriticalLevel
+	}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return h.l.Enabled(SlogLevel(lvl))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return h.l.LogC(logLine{
+		level:     SlogLevel(r.Level),
+		localCx:   withAttrs(h.attrs, h.groups, attrs),
+		requestCx: FromContext(ctx),
+		message:   r.Message,
+		time:      r.Time,
+		ownTime:   true,
+		pc:        r.PC,
+	})
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := *h
+	child.attrs = withAttrs(h.attrs, h.groups, attrs)
+	return &child
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &child
+}
+
+// withAttrs returns a copy of c with attrs added inside groups. Groups are
+// only created if there is something to put in them
+func withAttrs(c C, groups []string, attrs []slog.Attr) C {
+	fields := C{}
+	for _, a := range attrs {
+		addAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return c
+	}
+	return mergeInGroup(c, groups, fields)
+}
+
+func mergeInGroup(c C, groups []string, fields C) C {
+	merged := make(C, len(c)+len(fields))
+	for k, v := range c {
+		merged[k] = v
+	}
+	if len(groups) == 0 {
+		for k, v := range fields {
+			merged[k] = v
+		}
+		return merged
+	}
+	group, _ := merged[groups[0]].(C)
+	merged[groups[0]] = mergeInGroup(group, groups[1:], fields)
+	return merged
+}
+
+func addAttr(c C, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		c[a.Key] = a.Value.Any()
+		return
+	}
+	if a.Key == "" {
+		for _, ga := range a.Value.Group() {
+			addAttr(c, ga)
+		}
+		return
+	}
+	group := C{}
+	for _, ga := range a.Value.Group() {
+		addAttr(group, ga)
+	}
+	if len(group) > 0 {
+		c[a.Key] = group
+	}
+}
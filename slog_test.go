@@ -0,0 +1,98 @@
+package gologops
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buffer.Bytes(), []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatalf("%v in %s", err, line)
+			}
+			// logops calls the level "lvl"
+			m[slog.LevelKey] = m["lvl"]
+			delete(m, "lvl")
+			ms = append(ms, m)
+		}
+		return ms
+	}
+	if err := slogtest.TestHandler(NewSlogHandler(l), results); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	levels := map[slog.Level]Level{
+		slog.LevelDebug - 4: DebugLevel,
+		slog.LevelDebug:     DebugLevel,
+		slog.LevelInfo:      InfoLevel,
+		slog.LevelInfo + 1:  InfoLevel,
+		slog.LevelWarn:      WarnLevel,
+		slog.LevelError:     ErrorLevel,
+		slog.LevelError + 1: CriticalLevel,
+		slog.LevelError + 4: CriticalLevel,
+	}
+	for slogLevel, want := range levels {
+		if got := SlogLevel(slogLevel); got != want {
+			t.Errorf("level for %v: want %s, got %s", slogLevel, levelNames[want], levelNames[got])
+		}
+	}
+}
+
+func TestSlogEnabled(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetLevel(WarnLevel)
+	sl := slog.New(NewSlogHandler(l))
+	sl.Info("filtered")
+	sl.Warn("written")
+	if strings.Contains(buffer.String(), "filtered") || !strings.Contains(buffer.String(), "written") {
+		t.Errorf("level not honored: %s", buffer.Bytes())
+	}
+}
+
+func TestSlogTypesAndGroups(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetContext(C{"svc": "test"})
+	l.SetFlags(Lshortfile | Lmethod)
+	sl := slog.New(NewSlogHandler(l)).With("a", 1).WithGroup("req").With("id", "x")
+	sl.Error("failed", "latency", 2*time.Second, slog.Group("user", "admin", true))
+
+	var line struct {
+		Lvl  string
+		File string
+		Func string
+		Svc  string
+		A    int
+		Req  struct {
+			ID      string
+			Latency time.Duration
+			User    struct{ Admin bool }
+		}
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+		t.Fatalf("%v in %s", err, buffer.Bytes())
+	}
+	if line.Lvl != "ERROR" || line.Svc != "test" || line.A != 1 || line.Req.ID != "x" ||
+		line.Req.Latency != 2*time.Second || !line.Req.User.Admin {
+		t.Errorf("unexpected line %s", buffer.Bytes())
+	}
+	if !strings.HasPrefix(line.File, "slog_test.go.") || line.Func != "github.com/TDAF/gologops.TestSlogTypesAndGroups" {
+		t.Errorf("caller should be the slog call, got %q %q", line.File, line.Func)
+	}
+}
@@ -0,0 +1,62 @@
+package gologops
+
+import (
+	"log"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// stdWriter writes every line of a standard library log.Logger through a
+// Logger, at a fixed level
+type stdWriter struct {
+	l     *Logger
+	level Level
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	var pc uintptr
+	if atomic.LoadInt32(&w.l.flags)&(Llongfile|Lshortfile|Lmethod) != 0 {
+		pc = stdCallerPC()
+	}
+	message := strings.TrimSuffix(string(p), "\n")
+	if err := w.l.LogC(logLine{level: w.level, message: message, pc: pc}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// stdCallerPC returns the program counter of the first call out of the log
+// package, the one that gave the line to log
+func stdCallerPC() uintptr {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs) // skip Callers, stdCallerPC and Write
+	for i := range pcs[:n] {
+		frame, _ := runtime.CallersFrames(pcs[i : i+1]).Next()
+		if !strings.HasPrefix(frame.Function, "log.") && !strings.HasPrefix(frame.Function, "log/slog.") {
+			return pcs[i]
+		}
+	}
+	return 0
+}
+
+// StdLogger returns a standard library log.Logger writing through l at lvl,
+// for code that only knows about the log package
+func (l *Logger) StdLogger(lvl Level) *log.Logger {
+	return log.New(stdWriter{l, lvl}, "", 0)
+}
+
+// RedirectStdLog makes the standard log package write through l at lvl. Its
+// prefix and flags are cleared, so lines carry no date or prefix of their
+// own. The returned function restores the former output, prefix and flags
+func (l *Logger) RedirectStdLog(lvl Level) (restore func()) {
+	w, prefix, flags := log.Writer(), log.Prefix(), log.Flags()
+	log.SetOutput(stdWriter{l, lvl})
+	log.SetPrefix("")
+	log.SetFlags(0)
+	return func() {
+		log.SetOutput(w)
+		log.SetPrefix(prefix)
+		log.SetFlags(flags)
+	}
+}
@@ -0,0 +1,57 @@
+package gologops
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	l.SetFlags(Lshortfile | Lmethod)
+	l.SetContext(C{"svc": "std"})
+	std := l.StdLogger(WarnLevel)
+
+	std.Printf("%d apples", 3)
+	obj := decodeLine(t, &buffer)
+	if obj["lvl"] != "WARN" || obj["msg"] != "3 apples" || obj["svc"] != "std" {
+		t.Errorf("unexpected line %v", obj)
+	}
+	if file, _ := obj["file"].(string); !strings.HasPrefix(file, "stdlog_test.go.") {
+		t.Errorf("file should be the caller of Printf, got %v", obj["file"])
+	}
+	if obj["func"] != "github.com/TDAF/gologops.TestStdLogger" {
+		t.Errorf("func should be the caller of Printf, got %v", obj["func"])
+	}
+
+	std.Print("no newline added\n")
+	if obj := decodeLine(t, &buffer); obj["msg"] != "no newline added" {
+		t.Errorf("trailing newline not stripped: %q", obj["msg"])
+	}
+
+	l.SetLevel(ErrorLevel)
+	std.Print("filtered")
+	if buffer.Len() > 0 {
+		t.Errorf("level of logger not honored: %s", buffer.Bytes())
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	log.SetPrefix("lib: ")
+	restore := l.RedirectStdLog(InfoLevel)
+	log.Println("from the std log")
+	restore()
+
+	obj := decodeLine(t, &buffer)
+	if obj["lvl"] != "INFO" || obj["msg"] != "from the std log" {
+		t.Errorf("unexpected line %v", obj)
+	}
+	if log.Prefix() != "lib: " || log.Flags() != log.LstdFlags {
+		t.Errorf("std log not restored: prefix %q, flags %d", log.Prefix(), log.Flags())
+	}
+	log.SetPrefix("")
+}
@@ -0,0 +1,254 @@
+// Package syslog sends log lines as RFC 5424 syslog messages over UDP, TCP or
+// unix sockets. A Writer is both the formatter and the writer of a Logger:
+//
+//	w, err := syslog.Dial("udp", "localhost:514", syslog.Options{})
+//	l := gologops.NewLoggerWithFormatter(w, w)
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/TDAF/gologops"
+)
+
+// Facility of the messages, as in RFC 5424
+type Facility int
+
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	Local0 Facility = iota + 10
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// DefaultSDID is the SD-ID of the structured data carrying the context. 32473
+// is the enterprise number reserved for documentation by RFC 5612
+const DefaultSDID = "logops@32473"
+
+const timeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// Severity returns the syslog severity of a level
+func Severity(lvl gologops.Level) int {
+	switch {
+	case lvl >= gologops.CriticalLevel:
+		return 2 // critical
+	case lvl == gologops.ErrorLevel:
+		return 3 // error
+	case lvl == gologops.WarnLevel:
+		return 4 // warning
+	case lvl == gologops.InfoLevel:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
+
+// Options of a Writer. Empty fields get a default
+type Options struct {
+	Facility Facility // User if 0, so Kern cannot be used
+	Hostname string   // os.Hostname()
+	AppName  string   // base name of os.Args[0]
+	SDID     string   // DefaultSDID
+}
+
+// Writer formats lines as RFC 5424 messages and sends them, one per Write.
+// Stream connections use octet counting framing (RFC 6587). When a write
+// fails the Writer dials again and retries once
+type Writer struct {
+	network, addr string
+	opts          Options
+	procID        string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Dial connects to a syslog server. Network is "udp", "tcp", "unix" or
+// "unixgram"; for unix networks addr is the socket path
+func Dial(network, addr string, opts Options) (*Writer, error) {
+	if opts.Facility == 0 {
+		opts.Facility = User
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.AppName == "" {
+		opts.AppName = filepath.Base(os.Args[0])
+	}
+	if opts.SDID == "" {
+		opts.SDID = DefaultSDID
+	}
+	w := &Writer{network: network, addr: addr, opts: opts, procID: strconv.Itoa(os.Getpid())}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) connect() error {
+	conn, err := net.Dial(w.network, w.addr)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *Writer) stream() bool {
+	return w.network != "udp" && w.network != "udp4" && w.network != "udp6" && w.network != "unixgram"
+}
+
+// Format writes e as a RFC 5424 message, without trailing newline. Context
+// fields, error, file and func go as parameters of a structured data element
+func (w *Writer) Format(buffer *bytes.Buffer, e *gologops.Entry) {
+	pri := int(w.opts.Facility)*8 + Severity(e.Level)
+	fmt.Fprintf(buffer, "<%d>1 ", pri)
+	if e.Time.IsZero() {
+		buffer.WriteByte('-')
+	} else {
+		buffer.WriteString(e.Time.Format(timeFormat))
+	}
+	buffer.WriteByte(' ')
+	writeHeaderField(buffer, w.opts.Hostname, 255)
+	buffer.WriteByte(' ')
+	writeHeaderField(buffer, w.opts.AppName, 48)
+	buffer.WriteByte(' ')
+	writeHeaderField(buffer, w.procID, 128)
+	buffer.WriteString(" - ") // MSGID
+
+	params := make([][2]string, 0, len(e.Fields)+4)
+	if e.File != "" {
+		params = append(params, [2]string{"file", e.File})
+	}
+	if e.Func != "" {
+		params = append(params, [2]string{"func", e.Func})
+	}
+	if e.Err != nil {
+		params = append(params, [2]string{gologops.ErrFieldName, gologops.TextValue(e.Err)})
+	}
+	if e.Stack != nil {
+		params = append(params, [2]string{gologops.StackFieldName, e.Stack.String()})
+	}
+	for _, field := range e.Fields {
+		params = append(params, [2]string{field.Key, gologops.TextValue(field.Value)})
+	}
+	if len(params) == 0 {
+		buffer.WriteByte('-')
+	} else {
+		buffer.WriteByte('[')
+		buffer.WriteString(w.opts.SDID)
+		for _, param := range params {
+			buffer.WriteByte(' ')
+			writeParamName(buffer, param[0])
+			buffer.WriteString(`="`)
+			writeParamValue(buffer, param[1])
+			buffer.WriteByte('"')
+		}
+		buffer.WriteByte(']')
+	}
+	if e.Message != "" {
+		buffer.WriteByte(' ')
+		buffer.WriteString(e.Message)
+	}
+}
+
+// writeHeaderField writes s as printable US-ASCII of at most max bytes, "-" if empty
+func writeHeaderField(buffer *bytes.Buffer, s string, max int) {
+	if s == "" {
+		buffer.WriteByte('-')
+		return
+	}
+	for i := 0; i < len(s) && i < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			buffer.WriteByte(c)
+		} else {
+			buffer.WriteByte('_')
+		}
+	}
+}
+
+// writeParamName writes a valid SD-NAME: up to 32 printable US-ASCII
+// characters but '=', ' ', ']' and '"'
+func writeParamName(buffer *bytes.Buffer, name string) {
+	if name == "" {
+		name = "_"
+	}
+	for i := 0; i < len(name) && i < 32; i++ {
+		if c := name[i]; c > ' ' && c < 0x7f && c != '=' && c != ']' && c != '"' {
+			buffer.WriteByte(c)
+		} else {
+			buffer.WriteByte('_')
+		}
+	}
+}
+
+// writeParamValue writes value escaping '"', '\' and ']'
+func writeParamValue(buffer *bytes.Buffer, value string) {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c == '"' || c == '\\' || c == ']' {
+			buffer.WriteByte('\\')
+		}
+		buffer.WriteByte(value[i])
+	}
+}
+
+// Write sends p, a message produced by Format, reconnecting if needed
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		if err := w.send(p); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return 0, err
+	}
+	if err := w.send(p); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *Writer) send(p []byte) error {
+	p = bytes.TrimSuffix(p, []byte{'\n'})
+	if w.stream() {
+		// octet counting framing, so messages may have newlines
+		frame := strconv.AppendInt(make([]byte, 0, len(p)+8), int64(len(p)), 10)
+		p = append(append(frame, ' '), p...)
+	}
+	_, err := w.conn.Write(p)
+	return err
+}
+
+// Close closes the connection
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,164 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TDAF/gologops"
+)
+
+type testingError struct{ msg string }
+
+func (e *testingError) Error() string { return e.msg }
+
+var testOptions = Options{Facility: Local4, Hostname: "host", AppName: "app"}
+
+func TestFormat(t *testing.T) {
+	w := &Writer{opts: testOptions, procID: "42"}
+	w.opts.SDID = DefaultSDID
+	var buffer bytes.Buffer
+	e := gologops.Entry{
+		Time:    time.Date(2016, 1, 2, 3, 4, 5, 6000, time.UTC),
+		Level:   gologops.WarnLevel,
+		File:    "main.go.12",
+		Err:     errors.New("boom"),
+		Fields:  []gologops.Field{{Key: "a b", Value: `x"]\`}, {Key: "n", Value: 3}},
+		Message: "España y olé",
+	}
+	w.Format(&buffer, &e)
+	want := `<164>1 2016-01-02T03:04:05.000006Z host app 42 - [logops@32473 file="main.go.12" err="boom" a_b="x\"\]\\" n="3"] España y olé`
+	if got := buffer.String(); got != want {
+		t.Errorf("message:\nwant %s\ngot  %s", want, got)
+	}
+
+	buffer.Reset()
+	w.Format(&buffer, &gologops.Entry{Level: gologops.ErrorLevel, Err: (*testingError)(nil)})
+	if want := `<163>1 - host app 42 - [logops@32473 err="<nil>"]`; buffer.String() != want {
+		t.Errorf("nil pointer error: want %q, got %q", want, buffer.String())
+	}
+
+	buffer.Reset()
+	w.Format(&buffer, &gologops.Entry{Level: gologops.DebugLevel})
+	if want := "<167>1 - host app 42 - -"; buffer.String() != want {
+		t.Errorf("empty message: want %q, got %q", want, buffer.String())
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	severities := map[gologops.Level]int{
+		gologops.DebugLevel:    7,
+		gologops.InfoLevel:     6,
+		gologops.WarnLevel:     4,
+		gologops.ErrorLevel:    3,
+		gologops.CriticalLevel: 2,
+	}
+	for lvl, want := range severities {
+		if got := Severity(lvl); got != want {
+			t.Errorf("severity of %d: want %d, got %d", lvl, want, got)
+		}
+	}
+}
+
+func TestUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	w, err := Dial("udp", pc.LocalAddr().String(), testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := gologops.NewLoggerWithFormatter(w, w)
+	l.ErrorC(gologops.C{"corr": "123"}, "over udp")
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 2048)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(b[:n])
+	if !strings.HasPrefix(msg, "<163>1 ") || !strings.HasSuffix(msg, `[logops@32473 corr="123"] over udp`) {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+// readFrames reads octet counted messages from conn into messages
+func readFrames(conn net.Conn, messages chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		length, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			return
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return
+		}
+		messages <- string(b)
+	}
+}
+
+func TestTCPReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	messages := make(chan string, 100)
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	w, err := Dial("tcp", ln.Addr().String(), testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := gologops.NewLoggerWithFormatter(w, w)
+
+	first := <-conns
+	go readFrames(first, messages)
+	l.Info("line one\nline two")
+	if msg := <-messages; !strings.HasSuffix(msg, " - - line one\nline two") {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	first.Close() // the server goes away
+	deadline := time.After(5 * time.Second)
+	for {
+		l.Info("after reconnecting")
+		select {
+		case conn := <-conns:
+			go readFrames(conn, messages)
+		case msg := <-messages:
+			if strings.HasSuffix(msg, "after reconnecting") {
+				return
+			}
+		case <-deadline:
+			t.Fatal("writer did not reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
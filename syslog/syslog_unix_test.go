@@ -0,0 +1,39 @@
+//go:build unix
+
+package syslog
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TDAF/gologops"
+)
+
+func TestUnixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	w, err := Dial("unixgram", path, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := gologops.NewLoggerWithFormatter(w, w)
+	l.Warn("over a unix socket")
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 2048)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := string(b[:n]); !strings.HasPrefix(msg, "<164>1 ") || !strings.HasSuffix(msg, " - - over a unix socket") {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
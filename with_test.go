@@ -0,0 +1,85 @@
+package gologops
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeLine(t *testing.T, buffer *bytes.Buffer) map[string]interface{} {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(buffer.Bytes(), &obj); err != nil {
+		t.Fatalf("%v in %q", err, buffer.String())
+	}
+	delete(obj, "time")
+	buffer.Reset()
+	return obj
+}
+
+func TestWithContextPrecedence(t *testing.T) {
+	var buffer bytes.Buffer
+	parent := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	parent.SetContext(C{"svc": "parent", "layer": "parent", "dyn": "parent"})
+	parent.SetContextFunc(func() C { return C{"dyn": "func", "local": "func"} })
+	child := parent.With(C{"layer": "child", "req": "1"})
+	grandchild := child.With(C{"req": "2"})
+
+	grandchild.InfoC(C{"local": "local"}, "msg")
+	want := map[string]interface{}{
+		"lvl": "INFO", "msg": "msg",
+		"svc": "parent", "layer": "child", "req": "2", "dyn": "func", "local": "local",
+	}
+	if got := decodeLine(t, &buffer); !reflect.DeepEqual(got, want) {
+		t.Errorf("grandchild line: want %v, got %v", want, got)
+	}
+
+	parent.Info("msg")
+	want = map[string]interface{}{
+		"lvl": "INFO", "msg": "msg",
+		"svc": "parent", "layer": "parent", "dyn": "func", "local": "func",
+	}
+	if got := decodeLine(t, &buffer); !reflect.DeepEqual(got, want) {
+		t.Errorf("parent line changed by its children: want %v, got %v", want, got)
+	}
+
+	parent.SetContext(C{"svc": "changed"})
+	child.SetContextFunc(func() C { return C{"own": "func"} })
+	child.Info("msg")
+	want = map[string]interface{}{
+		"lvl": "INFO", "msg": "msg",
+		"svc": "changed", "layer": "child", "req": "1", "own": "func",
+	}
+	if got := decodeLine(t, &buffer); !reflect.DeepEqual(got, want) {
+		t.Errorf("child line: want %v, got %v", want, got)
+	}
+}
+
+func TestWithSharesOutput(t *testing.T) {
+	var buffer, other bytes.Buffer
+	parent := NewLoggerWithFormatter(&buffer, JSONFormatter{})
+	child := parent.With(C{"a": "b"})
+
+	parent.SetLevel(WarnLevel)
+	child.Info("filtered")
+	if buffer.Len() > 0 {
+		t.Errorf("child did not follow level of parent: %q", buffer.String())
+	}
+
+	child.SetWriter(&other)
+	child.SetLevel(InfoLevel)
+	parent.Info("to the new writer")
+	if buffer.Len() > 0 || other.Len() == 0 {
+		t.Errorf("parent did not follow writer set by child")
+	}
+}
+
+func TestWithErrField(t *testing.T) {
+	var buffer bytes.Buffer
+	l := NewLoggerWithFormatter(&buffer, JSONFormatter{}).With(C{ErrFieldName: "from context"})
+	l.ErrorE(testingNestedError{Text: "real"}, nil, "msg")
+	obj := decodeLine(t, &buffer)
+	if _, isObject := obj[ErrFieldName].(map[string]interface{}); !isObject {
+		t.Errorf("err field should be the error, got %v", obj[ErrFieldName])
+	}
+}
@@ -15,7 +15,7 @@ func (testingBadWriter) Write(b []byte) (int, error) {
 
 func TestWriteErr(t *testing.T) {
 	l := NewLoggerWithWriter(testingBadWriter{})
-	for lvlWanted := allLevel; lvlWanted < noneLevel; lvlWanted++ {
+	for lvlWanted := AllLevel; lvlWanted < NoneLevel; lvlWanted++ {
 		for _, msgWanted := range stringsForTesting {
 			err := l.LogC(logLine{level: lvlWanted, localCx: contextForTesting, message: msgWanted})
 			if err != errTestingBadWriter {